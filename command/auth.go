@@ -1,36 +1,41 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"syscall"
 
 	"github.com/wowu/pro/config"
-	"github.com/wowu/pro/provider/github"
-	"github.com/wowu/pro/provider/gitlab"
+	"github.com/wowu/pro/provider"
 
 	"github.com/fatih/color"
 	"golang.org/x/term"
 )
 
-func Auth(provider string) {
-	switch provider {
-	case "gitlab":
-		authgitlab()
-	case "github":
-		authgithub()
-	default:
-		fmt.Fprintln(os.Stderr, "unknown provider")
+// Ask for access token of given provider and save it in config. If host is
+// empty, the default host of the provider is used.
+func Auth(providerName string, host string) {
+	conf := config.Get()
+
+	p, err := provider.ByName(providerName, host, conf)
+	if errors.Is(err, provider.ErrHostRequired) {
+		fmt.Printf("Please specify host of %s instance with --host flag\n", providerName)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Printf("Please specify provider (%s)\n", strings.Join(provider.Names(), ", "))
 		os.Exit(1)
 	}
-}
 
-func authgitlab() {
-	fmt.Println("Generate your token at " + color.BlueString("https://gitlab.com/-/user_settings/personal_access_tokens?name=pro+cli&scopes=read_api"))
+	info := p.Info()
+
+	fmt.Println("Generate personal access token at " + color.BlueString(p.TokenURL()))
 	fmt.Println()
-	fmt.Println("The only required scope is 'read_api'")
-	color.Yellow("It's recommended to leave \"Expiration date\" blank.")
+	if info.TokenScopes != "" {
+		fmt.Printf("The only required scope is '%s'\n", info.TokenScopes)
+	}
+	color.Yellow(info.TokenHint)
 	fmt.Println()
 
 	// Ask for token
@@ -47,60 +52,21 @@ func authgitlab() {
 	}
 
 	// Check if token is valid by fetching user info
-	_, err = gitlab.User(token)
+	err = p.ValidateToken(token)
 	if err != nil {
-		switch err {
-		case gitlab.ErrUnauthorized:
+		if errors.Is(err, provider.ErrUnauthorized) {
 			color.Red("Token is invalid. Try again")
-			os.Exit(1)
-		default:
+		} else {
 			fmt.Println(err)
-			os.Exit(1)
 		}
-	}
-
-	conf := config.Get()
-	conf.GitLabToken = token
-	config.Save(conf)
-
-	color.Green("Saved.")
-}
-
-func authgithub() {
-	fmt.Println("Generate personal access token at " + color.BlueString("https://github.com/settings/tokens/new?description=pro+cli&scopes=repo"))
-	fmt.Println()
-	fmt.Println("The only required scope is 'repo'")
-	color.Yellow("It's recommended to set expiration to \"No expiration\"")
-	fmt.Println()
-
-	// ask for token
-	fmt.Print("Token: ")
-	byteToken, err := term.ReadPassword(int(syscall.Stdin))
-	fmt.Println()
-	handleError(err, "Error while reading token")
-
-	token := strings.TrimSpace(string(byteToken))
-
-	if token == "" {
-		color.Red("Token is empty. Try again")
 		os.Exit(1)
 	}
 
-	// Check if token is valid by fetching user info
-	_, err = github.User(token)
-	if err != nil {
-		switch err {
-		case github.ErrUnauthorized:
-			color.Red("Token is invalid. Try again")
-			os.Exit(1)
-		default:
-			fmt.Println(err)
-			os.Exit(1)
-		}
+	if provider.IsWellKnownHost(providerName, host) {
+		host = ""
 	}
 
-	conf := config.Get()
-	conf.GitHubToken = token
+	conf.SetToken(info.Name, host, token)
 	config.Save(conf)
 
 	color.Green("Saved.")
@@ -0,0 +1,239 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wowu/pro/giturl"
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Options of `pro browse`.
+type BrowseOptions struct {
+	// Remote to use, empty or "auto" to select it automatically.
+	Remote string
+
+	// Print URL instead of opening it in browser.
+	Print bool
+
+	// Copy URL to clipboard instead of opening it in browser.
+	Copy bool
+
+	// Use the repository current one is a submodule of.
+	Superproject bool
+
+	// Show the file at the current branch instead of the current commit. Its
+	// remote-tracking branch is used when it tracks one with another name.
+	CurrentBranch bool
+
+	// Branch to show the file at instead of the current commit.
+	Branch string
+
+	// Commit to show, or to show the file at, e.g. "HEAD~2" or SHA.
+	Commit string
+
+	// Tag to show, or to show the file at.
+	Tag string
+}
+
+var lineRangePattern = regexp.MustCompile(`^(\d+)(?:-(\d+))?$`)
+
+// Parse file argument of `pro browse` in "path[:line[-line]]" form. Suffix
+// which is not a line range is a part of the path.
+func ParseFileArg(arg string) (string, provider.Lines, error) {
+	path, suffix, found := cutLast(arg, ":")
+	if !found {
+		return arg, provider.Lines{}, nil
+	}
+
+	match := lineRangePattern.FindStringSubmatch(suffix)
+	if match == nil {
+		return arg, provider.Lines{}, nil
+	}
+
+	start, _ := strconv.Atoi(match[1])
+	end := start
+	if match[2] != "" {
+		end, _ = strconv.Atoi(match[2])
+	}
+
+	if start == 0 || end < start {
+		return "", provider.Lines{}, fmt.Errorf("invalid line range \"%s\"", suffix)
+	}
+
+	return path, provider.Lines{Start: start, End: end}, nil
+}
+
+func cutLast(s string, sep string) (before string, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
+
+// Open web page of given file of the repository, pinned to the current commit
+// or to given branch, commit or tag. Without file, page of given commit or tag
+// is opened.
+func Browse(repoPath string, fileArg string, options BrowseOptions) {
+	revisionFlags := 0
+	if options.CurrentBranch {
+		revisionFlags++
+	}
+	for _, flag := range []string{options.Branch, options.Commit, options.Tag} {
+		if flag != "" {
+			revisionFlags++
+		}
+	}
+
+	if revisionFlags > 1 {
+		fmt.Fprintln(os.Stderr, color.RedString("Use only one of --branch, --at-branch, --commit and --tag flags."))
+		os.Exit(1)
+	}
+
+	if fileArg == "" && options.Commit == "" && options.Tag == "" {
+		fmt.Fprintln(os.Stderr, color.RedString("File to browse is missing."))
+		fmt.Fprintln(os.Stderr, "Usage: pro browse <path>[:line[-line]]")
+		os.Exit(1)
+	}
+
+	repo := findRepository(repoPath, options.Superproject)
+
+	var revision provider.Revision
+	var gitURL *giturl.GitURL
+
+	switch {
+	case options.CurrentBranch:
+		branchName, err := repo.CurrentBranchName()
+		if errors.Is(err, repository.ErrNoActiveBranch) {
+			fmt.Fprintln(os.Stderr, color.RedString("Not on a branch, use --at-branch flag to choose one."))
+			os.Exit(1)
+		}
+		handleError(err, "Unable to get current branch")
+
+		branch, err := repo.ResolveBranch(branchName)
+		handleError(err, "Unable to read branch "+branchName)
+
+		fmt.Fprintf(os.Stderr, "Current branch: %s\n", color.GreenString(branchName))
+		if branch.Name != branchName {
+			fmt.Fprintf(os.Stderr, "Remote branch: %s\n", color.GreenString(branch.Name))
+		}
+
+		_, gitURL = selectBranchRemote(repo, options.Remote, branch)
+		revision = provider.Revision{Name: branch.Name, Kind: provider.RevisionBranch}
+
+		warnUnpushedHead(repo, branchName)
+	case options.Branch != "":
+		branch, err := repo.ResolveBranch(options.Branch)
+		handleError(err, "Unable to read branch "+options.Branch)
+
+		fmt.Fprintf(os.Stderr, "Branch: %s\n", color.GreenString(branch.Name))
+
+		_, gitURL = selectBranchRemote(repo, options.Remote, branch)
+		revision = provider.Revision{Name: branch.Name, Kind: provider.RevisionBranch}
+	case options.Commit != "":
+		sha, err := repo.ResolveCommit(options.Commit)
+		handleRevisionError(err, options.Commit)
+
+		fmt.Fprintf(os.Stderr, "Commit: %s\n", color.GreenString(sha[:7]))
+
+		_, gitURL = selectCurrentRemote(repo, options.Remote)
+		revision = provider.Revision{Name: sha, Kind: provider.RevisionCommit}
+	case options.Tag != "":
+		_, err := repo.ResolveTag(options.Tag)
+		handleRevisionError(err, options.Tag)
+
+		fmt.Fprintf(os.Stderr, "Tag: %s\n", color.GreenString(options.Tag))
+
+		_, gitURL = selectCurrentRemote(repo, options.Remote)
+		revision = provider.Revision{Name: options.Tag, Kind: provider.RevisionTag}
+	default:
+		sha, err := repo.HeadHash()
+		handleError(err, "Unable to read HEAD commit")
+
+		fmt.Fprintf(os.Stderr, "Commit: %s\n", color.GreenString(sha[:7]))
+
+		// Remote tracked by current branch is preferred, as for `pro open`
+		branch, err := repo.CurrentBranchName()
+		if err != nil && !errors.Is(err, repository.ErrNoActiveBranch) {
+			handleError(err, "Unable to get current branch")
+		}
+
+		_, gitURL = selectRemote(repo, options.Remote, branch)
+		revision = provider.Revision{Name: sha, Kind: provider.RevisionCommit}
+
+		if branch != "" {
+			warnUnpushedHead(repo, branch)
+		}
+	}
+
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
+
+	if fileArg == "" {
+		browser, ok := p.(provider.RevisionBrowser)
+		if !ok {
+			fmt.Fprintln(os.Stderr, color.RedString("Browsing commits and tags is not supported by %s.", p.Info().Title))
+			os.Exit(1)
+		}
+
+		if revision.Kind == provider.RevisionTag {
+			showURL(browser.TagURL(projectPath, revision.Name), options.Print, options.Copy)
+		} else {
+			showURL(browser.CommitURL(projectPath, revision.Name), options.Print, options.Copy)
+		}
+		return
+	}
+
+	path, lines, err := ParseFileArg(fileArg)
+	handleError(err, "Unable to parse file argument")
+
+	relPath, err := repo.RelativePath(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, color.RedString("File \"%s\" not found.", path))
+		} else if errors.Is(err, repository.ErrOutsideWorktree) {
+			fmt.Fprintln(os.Stderr, color.RedString("File \"%s\" is outside of the repository.", path))
+		} else {
+			fmt.Fprintln(os.Stderr, color.RedString("Unable to resolve file path: %s", err.Error()))
+		}
+		os.Exit(1)
+	}
+
+	browser, ok := p.(provider.FileBrowser)
+	if !ok {
+		fmt.Fprintln(os.Stderr, color.RedString("Browsing files is not supported by %s.", p.Info().Title))
+		os.Exit(1)
+	}
+
+	showURL(browser.FileURL(projectPath, revision, relPath, lines), options.Print, options.Copy)
+}
+
+// Print error and exit if revision given by user could not be resolved.
+func handleRevisionError(err error, rev string) {
+	if errors.Is(err, repository.ErrUnknownRevision) {
+		fmt.Fprintln(os.Stderr, color.RedString("Unknown revision \"%s\".", rev))
+		fmt.Fprintln(os.Stderr, "Fetch it from the remote or check its name.")
+		os.Exit(1)
+	}
+	handleError(err, "Unable to resolve "+rev)
+}
+
+// Warn if HEAD commit of given branch is missing in its remote-tracking
+// branch, as its pages don't exist until it's pushed.
+func warnUnpushedHead(repo repository.Repository, branch string) {
+	tracking, err := repo.Tracking(branch)
+	if err != nil || tracking.Ahead == 0 {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, color.YellowString("Branch is %d commit(s) ahead of %s/%s, the page may not exist until they are pushed.", tracking.Ahead, tracking.Remote, tracking.Branch))
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/wowu/pro/provider"
+)
+
+func TestParseFileArg(t *testing.T) {
+	tests := []struct {
+		arg       string
+		wantPath  string
+		wantLines provider.Lines
+	}{
+		{arg: "main.go", wantPath: "main.go"},
+		{arg: "main.go:10", wantPath: "main.go", wantLines: provider.Lines{Start: 10, End: 10}},
+		{arg: "main.go:10-20", wantPath: "main.go", wantLines: provider.Lines{Start: 10, End: 20}},
+		{arg: "docs/a:b.md", wantPath: "docs/a:b.md"},
+		{arg: "docs/a:b.md:3", wantPath: "docs/a:b.md", wantLines: provider.Lines{Start: 3, End: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			path, lines, err := ParseFileArg(tt.arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != tt.wantPath || lines != tt.wantLines {
+				t.Errorf("ParseFileArg(%q) = %q, %+v, want %q, %+v", tt.arg, path, lines, tt.wantPath, tt.wantLines)
+			}
+		})
+	}
+
+	for _, arg := range []string{"main.go:0", "main.go:20-10"} {
+		if _, _, err := ParseFileArg(arg); err == nil {
+			t.Errorf("ParseFileArg(%q) = nil error, want error", arg)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Options of `pro ci`.
+type CIOptions struct {
+	// Remote to use, empty or "auto" to select it automatically.
+	Remote string
+
+	// Print URL instead of opening it in browser.
+	Print bool
+
+	// Copy URL to clipboard instead of opening it in browser.
+	Copy bool
+
+	// Use the repository current one is a submodule of.
+	Superproject bool
+}
+
+// Open the latest CI pipeline of HEAD commit. When there is none, checks page
+// of the change request of current branch is opened instead.
+func CI(repoPath string, options CIOptions) {
+	repo := findRepository(repoPath, options.Superproject)
+
+	sha, err := repo.HeadHash()
+	handleError(err, "Unable to read HEAD commit")
+
+	branchName, err := repo.CurrentBranchName()
+	if err != nil && !errors.Is(err, repository.ErrNoActiveBranch) {
+		handleError(err, "Unable to get current branch")
+	}
+
+	if branchName != "" {
+		fmt.Fprintf(os.Stderr, "Current branch: %s\n", color.GreenString(branchName))
+	}
+	fmt.Fprintf(os.Stderr, "HEAD commit: %s\n", color.GreenString(sha[:7]))
+
+	remote, gitURL := selectRemote(repo, options.Remote, branchName)
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
+	info := p.Info()
+
+	ci, ok := p.(provider.CIProvider)
+	if !ok {
+		fmt.Fprintln(os.Stderr, color.RedString("CI pipelines are not supported for %s.", info.Title))
+		os.Exit(1)
+	}
+
+	if branchName != "" {
+		warnUnpushedHead(repo, branchName)
+	}
+
+	pipeline, err := ci.FindPipeline(projectPath, sha)
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "Pipeline: %s (%s)\n", color.GreenString(pipeline.Name), pipelineStatusString(pipeline.Status))
+		showURL(pipeline.URL, options.Print, options.Copy)
+		return
+	}
+	if !errors.Is(err, provider.ErrNotFound) {
+		handleProviderError(err, p, projectPath, "get pipelines")
+	}
+
+	fmt.Fprintln(os.Stderr, "No pipeline found for HEAD commit.")
+
+	if branchName == "" {
+		fmt.Fprintln(os.Stderr, "Make sure the commit is pushed and CI is set up for the project.")
+		os.Exit(1)
+	}
+
+	branch, err := repo.ResolveBranch(branchName)
+	handleError(err, "Unable to read branch "+branchName)
+
+	target := resolveTarget(repo, p, remote, gitURL, branch.Local, branch.Name)
+	changeRequest, found := findChangeRequest(p, target)
+	if !found {
+		fmt.Fprintf(os.Stderr, "No open %s found for current branch either.\n", info.RequestName)
+		fmt.Fprintln(os.Stderr, "Make sure the commit is pushed and CI is set up for the project.")
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Opening checks of %s %s%d.\n", info.RequestName, info.RefPrefix, changeRequest.Number)
+	showURL(ci.ChecksURL(changeRequest), options.Print, options.Copy)
+}
+
+// Return status of pipeline colored like on hosting sites.
+func pipelineStatusString(status string) string {
+	switch status {
+	case provider.PipelineSuccess:
+		return color.GreenString(status)
+	case provider.PipelineFailed:
+		return color.RedString(status)
+	case provider.PipelineRunning, provider.PipelinePending:
+		return color.YellowString(status)
+	default:
+		return status
+	}
+}
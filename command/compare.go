@@ -0,0 +1,111 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Options of `pro compare`.
+type CompareOptions struct {
+	// Remote to use, empty or "auto" to select it automatically.
+	Remote string
+
+	// Print URL instead of opening it in browser.
+	Print bool
+
+	// Copy URL to clipboard instead of opening it in browser.
+	Copy bool
+
+	// Use the repository current one is a submodule of.
+	Superproject bool
+}
+
+// Parse revision range in "base..head" or "base...head" form. Either side may
+// be empty, which means HEAD like in git.
+func ParseRange(arg string) (base string, head string, err error) {
+	base, head, found := strings.Cut(arg, "...")
+	if !found {
+		base, head, found = strings.Cut(arg, "..")
+	}
+
+	if !found || strings.Contains(head, "..") {
+		return "", "", fmt.Errorf("invalid range \"%s\", expected base..head", arg)
+	}
+
+	return base, head, nil
+}
+
+// Open page comparing two revisions of the repository.
+func Compare(repoPath string, rangeArg string, options CompareOptions) {
+	if rangeArg == "" {
+		fmt.Fprintln(os.Stderr, color.RedString("Revisions to compare are missing."))
+		fmt.Fprintln(os.Stderr, "Usage: pro compare <base>..<head>")
+		os.Exit(1)
+	}
+
+	baseName, headName, err := ParseRange(rangeArg)
+	handleError(err, "Unable to parse range")
+
+	repo := findRepository(repoPath, options.Superproject)
+
+	base := resolveRevision(repo, baseName)
+	head := resolveRevision(repo, headName)
+
+	fmt.Fprintf(os.Stderr, "Comparing: %s...%s\n", color.GreenString(base.Name), color.GreenString(head.Name))
+
+	_, gitURL := selectCurrentRemote(repo, options.Remote)
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
+
+	comparer, ok := p.(provider.Comparer)
+	if !ok {
+		fmt.Fprintln(os.Stderr, color.RedString("Comparing revisions is not supported by %s.", p.Info().Title))
+		os.Exit(1)
+	}
+
+	showURL(comparer.CompareURL(projectPath, base, head), options.Print, options.Copy)
+}
+
+// Resolve revision given by user, exit if it's unknown. Like in git, tags take
+// precedence over branches, other revisions are resolved to commit SHA. Empty
+// name or HEAD means the current branch, or HEAD commit when it's detached.
+func resolveRevision(repo repository.Repository, name string) provider.Revision {
+	if name == "" || name == "HEAD" {
+		current, err := repo.CurrentBranchName()
+		if errors.Is(err, repository.ErrNoActiveBranch) {
+			sha, err := repo.HeadHash()
+			handleError(err, "Unable to read HEAD commit")
+			return provider.Revision{Name: sha, Kind: provider.RevisionCommit}
+		}
+		handleError(err, "Unable to get current branch")
+
+		name = current
+	}
+
+	_, err := repo.ResolveTag(name)
+	if err == nil {
+		return provider.Revision{Name: name, Kind: provider.RevisionTag}
+	}
+	if !errors.Is(err, repository.ErrUnknownRevision) {
+		handleError(err, "Unable to resolve "+name)
+	}
+
+	// Branches are compared by their names in the remote
+	branch, err := repo.ResolveBranch(name)
+	handleError(err, "Unable to read branch "+name)
+	if branch.Local != "" || branch.Remote != "" {
+		return provider.Revision{Name: branch.Name, Kind: provider.RevisionBranch}
+	}
+
+	sha, err := repo.ResolveCommit(name)
+	handleRevisionError(err, name)
+
+	return provider.Revision{Name: sha, Kind: provider.RevisionCommit}
+}
@@ -0,0 +1,35 @@
+package command
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		arg      string
+		wantBase string
+		wantHead string
+	}{
+		{arg: "main..feature", wantBase: "main", wantHead: "feature"},
+		{arg: "main...feature", wantBase: "main", wantHead: "feature"},
+		{arg: "v1.0..", wantBase: "v1.0", wantHead: ""},
+		{arg: "..origin/feature", wantBase: "", wantHead: "origin/feature"},
+		{arg: "HEAD~3..HEAD", wantBase: "HEAD~3", wantHead: "HEAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			base, head, err := ParseRange(tt.arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if base != tt.wantBase || head != tt.wantHead {
+				t.Errorf("ParseRange(%q) = %q, %q, want %q, %q", tt.arg, base, head, tt.wantBase, tt.wantHead)
+			}
+		})
+	}
+
+	for _, arg := range []string{"main", "a..b..c"} {
+		if _, _, err := ParseRange(arg); err == nil {
+			t.Errorf("ParseRange(%q) = nil error, want error", arg)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Shell completion scripts, based on the ones shipped with urfave/cli.
+var completionScripts = map[string]string{
+	"bash": `_pro_bash_autocomplete() {
+  local cur opts words
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  words=("${COMP_WORDS[@]:0:$COMP_CWORD}")
+  if [[ "$cur" == "-"* ]]; then
+    opts=$("${words[@]}" "$cur" --generate-bash-completion 2>/dev/null)
+  else
+    opts=$("${words[@]}" --generate-bash-completion 2>/dev/null)
+  fi
+  COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+  return 0
+}
+
+complete -o bashdefault -o default -F _pro_bash_autocomplete pro
+`,
+	"zsh": `#compdef pro
+
+_pro_zsh_autocomplete() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    compadd -a opts
+  else
+    _files
+  fi
+}
+
+compdef _pro_zsh_autocomplete pro
+`,
+}
+
+// Print shell completion script for given shell.
+func Completion(shell string) {
+	script, ok := completionScripts[shell]
+	if !ok {
+		fmt.Fprintln(os.Stderr, color.RedString("Unsupported shell \"%s\".", shell))
+		fmt.Fprintln(os.Stderr, "Supported shells: bash, zsh")
+		os.Exit(1)
+	}
+
+	fmt.Print(script)
+}
+
+// Print names of local and remote-tracking branches for shell completion.
+// Errors are ignored, there is just nothing to complete.
+func CompleteBranches(repoPath string) {
+	repo, err := repository.FindInParents(repoPath)
+	if err != nil {
+		return
+	}
+
+	names, err := repo.BranchNames()
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
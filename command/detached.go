@@ -0,0 +1,65 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Open change request containing HEAD commit when HEAD is detached, e.g.
+// during rebase, bisect or in CI checkouts.
+func openDetached(repo repository.Repository, remoteFlag string, print bool, copy bool) {
+	sha, err := repo.HeadHash()
+	handleError(err, "Unable to read HEAD commit")
+
+	fmt.Fprintf(os.Stderr, "Detached HEAD: %s\n", color.GreenString(sha[:7]))
+
+	_, gitURL := selectRemote(repo, remoteFlag, "")
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
+	requestName := p.Info().RequestName
+
+	if matcher, ok := p.(provider.CommitMatcher); ok {
+		changeRequest, found := findCommitChangeRequest(matcher, p, repo, projectPath, sha, "HEAD commit")
+		if !found {
+			fmt.Fprintf(os.Stderr, "No %s found for HEAD commit.\n", requestName)
+			os.Exit(0)
+		}
+
+		showURL(changeRequest.URL, print, copy)
+		return
+	}
+
+	lookup, ok := p.(provider.CommitLookup)
+	if !ok {
+		fmt.Fprintln(os.Stderr, color.RedString("No active branch found."))
+		fmt.Fprintln(os.Stderr, "Switch to a branch and try again.")
+		os.Exit(0)
+	}
+
+	changeRequests, err := lookup.ChangeRequestsForCommit(projectPath, sha)
+	if err != nil && !errors.Is(err, provider.ErrNotFound) {
+		handleProviderError(err, p, projectPath, "get "+requestName+"s")
+	}
+
+	if len(changeRequests) == 0 {
+		fmt.Fprintf(os.Stderr, "No %s found for HEAD commit.\n", requestName)
+		fmt.Fprintln(os.Stderr, "Make sure the commit is pushed, or switch to a branch and try again.")
+		os.Exit(0)
+	}
+
+	changeRequest := changeRequests[0]
+	if len(changeRequests) > 1 {
+		changeRequest, ok = pickChangeRequest(p, changeRequests)
+		if !ok {
+			return
+		}
+	}
+
+	showURL(changeRequest.URL, print, copy)
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/wowu/pro/giturl"
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Branch a change request is looked up for, and repositories it's opened
+// between. Base and head projects differ when the branch is pushed to a fork.
+type changeTarget struct {
+	// BasePath is the path of the project change request targets.
+	BasePath string
+
+	// HeadPath is the path of the project the branch is pushed to.
+	HeadPath string
+
+	// HeadRemote is the git remote of the head project.
+	HeadRemote string
+
+	// LocalBranch is the name of the local branch.
+	LocalBranch string
+
+	// Branch is the name of the branch in the head project.
+	Branch string
+}
+
+func (t changeTarget) isFork() bool {
+	return t.BasePath != t.HeadPath
+}
+
+// Return target of change request from given branch. Fork is detected from
+// the parent of the repository reported by the API, or from the selected
+// remote (e.g. "upstream") being other than the remote the branch is pushed to.
+func resolveTarget(repo repository.Repository, p provider.Provider, remote repository.Remote, gitURL *giturl.GitURL, localBranch string, branch string) changeTarget {
+	projectPath := projectPathFromURL(gitURL)
+	target := changeTarget{
+		BasePath:    projectPath,
+		HeadPath:    projectPath,
+		HeadRemote:  remote.Name,
+		LocalBranch: localBranch,
+		Branch:      branch,
+	}
+
+	forkProvider, ok := p.(provider.ForkProvider)
+	if !ok {
+		return target
+	}
+
+	// Remote-tracking branches are already in the head project
+	pushRemote := ""
+	if localBranch != "" {
+		var err error
+		pushRemote, err = repo.PushRemote(localBranch)
+		handleError(err, "Unable to read git config")
+	}
+
+	if pushRemote != "" && pushRemote != remote.Name {
+		rawURL, err := repo.RemoteURL(pushRemote)
+		if err == nil {
+			pushURL, err := giturl.Parse(rawURL)
+			if err == nil && pushURL.Host == gitURL.Host {
+				target.HeadPath = projectPathFromURL(pushURL)
+				target.HeadRemote = pushRemote
+			}
+		}
+	}
+
+	if !target.isFork() {
+		parent, err := forkProvider.ForkParent(projectPath)
+		if err != nil {
+			handleProviderError(err, p, projectPath, "get repository")
+		}
+
+		if parent == "" {
+			return target
+		}
+		target.BasePath = parent
+	}
+
+	fmt.Fprintf(os.Stderr, "Fork: %s of %s\n", color.GreenString(target.HeadPath), color.GreenString(target.BasePath))
+
+	return target
+}
@@ -0,0 +1,209 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/wowu/pro/giturl"
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/provider/github"
+	"github.com/wowu/pro/provider/gitlab"
+	"github.com/wowu/pro/repository"
+
+	"github.com/go-git/go-git/v6"
+)
+
+// Create repository with given git config, returning it opened like by pro.
+func initForkRepository(t *testing.T, config string) repository.Repository {
+	t.Helper()
+
+	path := t.TempDir()
+	_, err := git.PlainInit(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(path, ".git", "config"), []byte(config), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := repository.FindInParents(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repo
+}
+
+// Start API server answering requests with given bodies by request URI, and
+// with 404 to other ones. Returned function lists requested URIs.
+func startAPIServer(t *testing.T, responses map[string]string) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		mu.Unlock()
+
+		body, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(requests)
+	}
+}
+
+func remoteConfig(name string, url string) string {
+	return "[remote \"" + name + "\"]\n\turl = " + url + "\n"
+}
+
+var githubForkResponses = map[string]string{
+	"/repos/jdoe/pro":                                    `{"full_name": "jdoe/pro", "default_branch": "main", "fork": true, "parent": {"full_name": "wowu/pro"}}`,
+	"/repos/wowu/pro":                                    `{"full_name": "wowu/pro", "default_branch": "main", "fork": false}`,
+	"/repos/jdoe/pro/git/refs/heads":                     `[{"ref": "refs/heads/main"}, {"ref": "refs/heads/feature"}]`,
+	"/repos/wowu/pro/git/refs/heads":                     `[{"ref": "refs/heads/main"}, {"ref": "refs/heads/feature"}]`,
+	"/repos/wowu/pro/pulls?state=open&head=jdoe:feature": `[]`,
+	"/repos/wowu/pro/pulls?state=open&head=wowu:feature": `[]`,
+}
+
+var gitlabForkResponses = map[string]string{
+	"/projects/jdoe%2Fpro":                     `{"id": 2, "path_with_namespace": "jdoe/pro", "default_branch": "main", "forked_from_project": {"id": 1, "path_with_namespace": "wowu/pro"}}`,
+	"/projects/wowu%2Fpro":                     `{"id": 1, "path_with_namespace": "wowu/pro", "default_branch": "main"}`,
+	"/projects/jdoe%2Fpro/repository/branches": `[{"name": "main"}, {"name": "feature"}]`,
+	"/projects/wowu%2Fpro/repository/branches": `[{"name": "main"}, {"name": "feature"}]`,
+	"/projects/wowu%2Fpro/merge_requests?state=opened&source_branch=feature&source_project_id=2": `[]`,
+	"/projects/wowu%2Fpro/merge_requests?state=opened&source_branch=feature":                     `[]`,
+}
+
+func TestForkChangeRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		host        string
+		newProvider func(provider.Instance) provider.Provider
+		responses   map[string]string
+		config      string
+		remote      string
+		wantTarget  changeTarget
+		wantQuery   string
+		wantURL     string
+	}{
+		{
+			name:        "GitHub fork found by API parent",
+			host:        "github.com",
+			newProvider: github.New,
+			responses:   githubForkResponses,
+			config:      remoteConfig("origin", "git@github.com:jdoe/pro.git"),
+			remote:      "origin",
+			wantTarget:  changeTarget{BasePath: "wowu/pro", HeadPath: "jdoe/pro", HeadRemote: "origin", LocalBranch: "feature", Branch: "feature"},
+			wantQuery:   "/repos/wowu/pro/pulls?state=open&head=jdoe:feature",
+			wantURL:     "https://github.com/wowu/pro/compare/main...jdoe:feature?expand=1",
+		},
+		{
+			name:        "GitHub fork found by upstream remote",
+			host:        "github.com",
+			newProvider: github.New,
+			responses:   githubForkResponses,
+			config:      remoteConfig("origin", "git@github.com:jdoe/pro.git") + remoteConfig("upstream", "git@github.com:wowu/pro.git"),
+			remote:      "upstream",
+			wantTarget:  changeTarget{BasePath: "wowu/pro", HeadPath: "jdoe/pro", HeadRemote: "origin", LocalBranch: "feature", Branch: "feature"},
+			wantQuery:   "/repos/wowu/pro/pulls?state=open&head=jdoe:feature",
+			wantURL:     "https://github.com/wowu/pro/compare/main...jdoe:feature?expand=1",
+		},
+		{
+			name:        "GitHub repository that is not a fork",
+			host:        "github.com",
+			newProvider: github.New,
+			responses:   githubForkResponses,
+			config:      remoteConfig("origin", "git@github.com:wowu/pro.git"),
+			remote:      "origin",
+			wantTarget:  changeTarget{BasePath: "wowu/pro", HeadPath: "wowu/pro", HeadRemote: "origin", LocalBranch: "feature", Branch: "feature"},
+			wantQuery:   "/repos/wowu/pro/pulls?state=open&head=wowu:feature",
+			wantURL:     "https://github.com/wowu/pro/pull/new/feature",
+		},
+		{
+			name:        "GitLab fork found by API parent",
+			host:        "gitlab.com",
+			newProvider: gitlab.New,
+			responses:   gitlabForkResponses,
+			config:      remoteConfig("origin", "git@gitlab.com:jdoe/pro.git"),
+			remote:      "origin",
+			wantTarget:  changeTarget{BasePath: "wowu/pro", HeadPath: "jdoe/pro", HeadRemote: "origin", LocalBranch: "feature", Branch: "feature"},
+			wantQuery:   "/projects/wowu%2Fpro/merge_requests?state=opened&source_branch=feature&source_project_id=2",
+			wantURL:     "https://gitlab.com/jdoe/pro/merge_requests/new?merge_request%5Bsource_branch%5D=feature&merge_request%5Bsource_project_id%5D=2&merge_request%5Btarget_project_id%5D=1",
+		},
+		{
+			name:        "GitLab fork found by upstream remote",
+			host:        "gitlab.com",
+			newProvider: gitlab.New,
+			responses:   gitlabForkResponses,
+			config:      remoteConfig("origin", "git@gitlab.com:jdoe/pro.git") + remoteConfig("upstream", "git@gitlab.com:wowu/pro.git"),
+			remote:      "upstream",
+			wantTarget:  changeTarget{BasePath: "wowu/pro", HeadPath: "jdoe/pro", HeadRemote: "origin", LocalBranch: "feature", Branch: "feature"},
+			wantQuery:   "/projects/wowu%2Fpro/merge_requests?state=opened&source_branch=feature&source_project_id=2",
+			wantURL:     "https://gitlab.com/jdoe/pro/merge_requests/new?merge_request%5Bsource_branch%5D=feature&merge_request%5Bsource_project_id%5D=2&merge_request%5Btarget_project_id%5D=1",
+		},
+		{
+			name:        "GitLab repository that is not a fork",
+			host:        "gitlab.com",
+			newProvider: gitlab.New,
+			responses:   gitlabForkResponses,
+			config:      remoteConfig("origin", "git@gitlab.com:wowu/pro.git"),
+			remote:      "origin",
+			wantTarget:  changeTarget{BasePath: "wowu/pro", HeadPath: "wowu/pro", HeadRemote: "origin", LocalBranch: "feature", Branch: "feature"},
+			wantQuery:   "/projects/wowu%2Fpro/merge_requests?state=opened&source_branch=feature",
+			wantURL:     "https://gitlab.com/wowu/pro/merge_requests/new?merge_request%5Bsource_branch%5D=feature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := startAPIServer(t, tt.responses)
+			p := tt.newProvider(provider.Instance{Host: tt.host, APIURL: server.URL, WebURL: "https://" + tt.host, Token: "token"})
+
+			repo := initForkRepository(t, tt.config)
+
+			rawURL, err := repo.RemoteURL(tt.remote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gitURL, err := giturl.Parse(rawURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			target := resolveTarget(repo, p, repository.Remote{Name: tt.remote}, gitURL, "feature", "feature")
+			if target != tt.wantTarget {
+				t.Errorf("resolveTarget() = %+v, want %+v", target, tt.wantTarget)
+			}
+
+			if _, found := findChangeRequest(p, target); found {
+				t.Errorf("findChangeRequest() found change request, want none")
+			}
+			if !slices.Contains(requests(), tt.wantQuery) {
+				t.Errorf("change request was not queried with %q, requests: %v", tt.wantQuery, requests())
+			}
+
+			if got := createChangeRequestUrl(p, repo, target, false); got != tt.wantURL {
+				t.Errorf("createChangeRequestUrl() = %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
@@ -1,72 +1,52 @@
 package command
 
 import (
-	"errors"
 	"fmt"
-	"os"
-	"strings"
 
-	"github.com/fatih/color"
 	"github.com/ktr0731/go-fuzzyfinder"
-	"github.com/wowu/pro/config"
-	"github.com/wowu/pro/giturl"
-	"github.com/wowu/pro/provider/github"
-	"github.com/wowu/pro/provider/gitlab"
-	"github.com/wowu/pro/repository"
+	"github.com/wowu/pro/provider"
 )
 
-func List(repoPath string, print bool, copy bool) {
-	repo, err := repository.FindInParents(repoPath)
-	if err != nil {
-		if errors.Is(err, repository.ErrNoRepository) {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to find git repository in given directory or any of parent directories."))
-			fmt.Fprintln(os.Stderr, "Please make sure you are in the project directory.")
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to open git repository: %s", err.Error()))
-		}
-		os.Exit(1)
-	}
+// Options of `pro list`.
+type ListOptions struct {
+	// Remote to use, empty or "auto" to select it automatically.
+	Remote string
 
-	originURL, err := repo.OriginUrl()
-	if err != nil {
-		if errors.Is(err, repository.ErrNoRemoteOrigin) {
-			fmt.Fprintln(os.Stderr, color.RedString("No remote named \"origin\" found."))
-			fmt.Fprintln(os.Stderr, "Please make sure you have a remote named \"origin\".")
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get origin URL: %s", err.Error()))
-		}
-		os.Exit(1)
-	}
+	// Print URL instead of opening it in browser.
+	Print bool
 
-	gitURL, err := giturl.Parse(originURL)
-	handleError(err, "Unable to parse origin URL")
+	// Copy URL to clipboard instead of opening it in browser.
+	Copy bool
+
+	// Use the repository current one is a submodule of.
+	Superproject bool
+}
 
-	projectPath := strings.TrimPrefix(gitURL.Path, "/")
-	projectPath = strings.TrimSuffix(projectPath, ".git")
+func List(repoPath string, options ListOptions) {
+	repo := findRepository(repoPath, options.Superproject)
+
+	// Branch is used only to select the remote, so detached HEAD is fine
+	branch, _ := repo.CurrentBranchName()
+
+	_, gitURL := selectRemote(repo, options.Remote, branch)
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
 
 	var prTitles []string
 	var prUrls []string
 
 	// Append repository homepage
 	prTitles = append(prTitles, fmt.Sprintf("Repository homepage (%s)", projectPath))
-	prUrls = append(prUrls, fmt.Sprintf("https://%s/%s", gitURL.Host, projectPath))
-
-	switch gitURL.Host {
-	case "github.com":
-		prs := getGitHubOpenPullRequests(projectPath)
-		for _, pr := range prs {
-			prTitles = append(prTitles, fmt.Sprintf("%s (#%d)", pr.Title, pr.Number))
-			prUrls = append(prUrls, pr.HtmlURL)
-		}
-	case "gitlab.com":
-		mrs := getGitLabOpenMergeRequests(projectPath)
-		for _, mr := range mrs {
-			prTitles = append(prTitles, fmt.Sprintf("%s (!%d)", mr.Title, mr.IID))
-			prUrls = append(prUrls, mr.WebUrl)
-		}
-	default:
-		fmt.Fprintln(os.Stderr, "Unknown remote type")
-		os.Exit(1)
+	prUrls = append(prUrls, p.HomeURL(projectPath))
+
+	changeRequests, err := p.ListOpenChangeRequests(projectPath)
+	if err != nil {
+		handleProviderError(err, p, projectPath, "get "+p.Info().RequestName+"s")
+	}
+
+	for _, cr := range changeRequests {
+		prTitles = append(prTitles, fmt.Sprintf("%s (%s%d)", cr.Title, p.Info().RefPrefix, cr.Number))
+		prUrls = append(prUrls, cr.URL)
 	}
 
 	if len(prTitles) == 0 {
@@ -87,57 +67,23 @@ func List(repoPath string, print bool, copy bool) {
 		handleError(err, "Fuzzyfinder failed")
 	}
 
-	if print {
-		color.Blue(prUrls[idx])
-	} else if copy {
-		copyToClipboard(prUrls[idx])
-		fmt.Fprintln(os.Stderr, "Copied to clipboard: "+color.BlueString(prUrls[idx]))
-	} else {
-		fmt.Fprintln(os.Stderr, "Opening "+color.BlueString(prUrls[idx]))
-		openBrowser(prUrls[idx])
-	}
+	showURL(prUrls[idx], options.Print, options.Copy)
 }
 
-func getGitHubOpenPullRequests(projectPath string) []github.PullRequestResponse {
-	githubToken := config.Get().GitHubToken
-	if githubToken == "" {
-		color.Red("GitHub token is not set. Run `pro auth github` to set it.")
-		os.Exit(1)
-	}
-	prs, err := github.ListOpenPullRequests(projectPath, githubToken)
+// Let user pick one of given change requests, returns false if aborted.
+func pickChangeRequest(p provider.Provider, changeRequests []provider.ChangeRequest) (provider.ChangeRequest, bool) {
+	idx, err := fuzzyfinder.Find(
+		changeRequests,
+		func(i int) string {
+			return fmt.Sprintf("%s (%s%d)", changeRequests[i].Title, p.Info().RefPrefix, changeRequests[i].Number)
+		},
+	)
 	if err != nil {
-		if errors.Is(err, github.ErrUnauthorized) {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get pull requests: %s", err.Error()))
-			fmt.Fprintln(os.Stderr, "Token may be expired or deleted. Run `pro auth github` to connect GitHub again.")
-			os.Exit(1)
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get pull requests: %s", err.Error()))
-			os.Exit(1)
+		if err == fuzzyfinder.ErrAbort {
+			return provider.ChangeRequest{}, false
 		}
+		handleError(err, "Fuzzyfinder failed")
 	}
-	return prs
-}
 
-func getGitLabOpenMergeRequests(projectPath string) []gitlab.MergeRequestResponse {
-	gitlabToken := config.Get().GitLabToken
-	if gitlabToken == "" {
-		color.Red("GitLab token is not set. Run `pro auth gitlab` to set it.")
-		os.Exit(1)
-	}
-	mrs, err := gitlab.ListOpenMergeRequests(projectPath, gitlabToken)
-	if err != nil {
-		if errors.Is(err, gitlab.ErrUnauthorized) || errors.Is(err, gitlab.ErrTokenExpired) {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get merge requests: %s", err.Error()))
-			fmt.Fprintln(os.Stderr, "Connect GitLab again with `pro auth gitlab`.")
-			os.Exit(1)
-		} else if errors.Is(err, gitlab.ErrProjectNotFound) {
-			fmt.Fprintln(os.Stderr, color.RedString("Project not found: %s", projectPath))
-			fmt.Fprintln(os.Stderr, "Maybe it was renamed or deleted? Change remote URL and try again.")
-			os.Exit(1)
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get merge requests: %s", err.Error()))
-			os.Exit(1)
-		}
-	}
-	return mrs
+	return changeRequests[idx], true
 }
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Parse change request reference given on the command line, e.g. "#1234",
+// "!56" or "1234". Returns false if it's not a reference, e.g. a branch name.
+func ParseChangeRequestRef(ref string) (int, bool) {
+	digits := ref
+	if strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "!") {
+		digits = ref[1:]
+	}
+
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(digits)
+	if err != nil || number == 0 {
+		return 0, false
+	}
+
+	return number, true
+}
+
+// Open change request with given number in the selected remote, after checking
+// that it exists.
+func openNumber(repo repository.Repository, remoteFlag string, number int, print bool, copy bool) {
+	// Remote tracked by current branch is preferred, as for `pro open`
+	_, gitURL := selectCurrentRemote(repo, remoteFlag)
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
+	info := p.Info()
+
+	changeRequest, err := p.GetChangeRequest(projectPath, number)
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			fmt.Fprintln(os.Stderr, color.RedString("%s %s%d not found in %s.", capitalize(info.RequestName), info.RefPrefix, number, projectPath))
+			os.Exit(1)
+		}
+		handleProviderError(err, p, projectPath, "get "+info.RequestName)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s %s%d: %s (%s)\n", capitalize(info.RequestName), info.RefPrefix, number,
+		color.GreenString(changeRequest.Title), stateString(changeRequest.State))
+
+	showURL(changeRequest.URL, print, copy)
+}
+
+// Return state of change request colored like on hosting sites.
+func stateString(state string) string {
+	switch state {
+	case provider.StateOpen:
+		return color.GreenString(state)
+	case provider.StateMerged:
+		return color.MagentaString(state)
+	case provider.StateClosed:
+		return color.RedString(state)
+	default:
+		return state
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+import "testing"
+
+func TestParseChangeRequestRef(t *testing.T) {
+	tests := []struct {
+		ref    string
+		want   int
+		wantOk bool
+	}{
+		{ref: "#1234", want: 1234, wantOk: true},
+		{ref: "!56", want: 56, wantOk: true},
+		{ref: "1234", want: 1234, wantOk: true},
+		{ref: "feature-x", wantOk: false},
+		{ref: "#", wantOk: false},
+		{ref: "#!56", wantOk: false},
+		{ref: "+56", wantOk: false},
+		{ref: "0", wantOk: false},
+		{ref: "12a", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, ok := ParseChangeRequestRef(tt.ref)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ParseChangeRequestRef(%q) = %d, %v, want %d, %v", tt.ref, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -6,99 +6,197 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
-	"strings"
 
 	"github.com/wowu/pro/config"
-	"github.com/wowu/pro/giturl"
-	"github.com/wowu/pro/provider/github"
-	"github.com/wowu/pro/provider/gitlab"
+	"github.com/wowu/pro/provider"
 	"github.com/wowu/pro/repository"
 
 	"github.com/atotto/clipboard"
 	"github.com/fatih/color"
 )
 
-func Open(repoPath string, print bool, copy bool) {
-	repo, err := repository.FindInParents(repoPath)
-	if err != nil {
-		if errors.Is(err, repository.ErrNoRepository) {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to find git repository in given directory or any of parent directories."))
-			fmt.Fprintln(os.Stderr, "Please make sure you are in the project directory.")
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to open git repository: %s", err.Error()))
-		}
-		os.Exit(1)
+// Options of `pro open`.
+type OpenOptions struct {
+	// Remote to use, empty or "auto" to select it automatically.
+	Remote string
+
+	// Print URL instead of opening it in browser.
+	Print bool
+
+	// Copy URL to clipboard instead of opening it in browser.
+	Copy bool
+
+	// Push unpushed commits of current branch before opening.
+	Push bool
+
+	// Branch to open change request for instead of the current one. May be a
+	// local branch, a remote-tracking branch or a branch only in the remote.
+	Branch string
+
+	// Number of change request to open instead of the one of a branch.
+	Number int
+
+	// Use the repository current one is a submodule of.
+	Superproject bool
+}
+
+func Open(repoPath string, options OpenOptions) {
+	print, copy := options.Print, options.Copy
+	repo := findRepository(repoPath, options.Superproject)
+
+	if options.Number != 0 {
+		openNumber(repo, options.Remote, options.Number, print, copy)
+		return
 	}
 
-	originURL, err := repo.OriginUrl()
-	if err != nil {
-		if errors.Is(err, repository.ErrNoRemoteOrigin) {
-			fmt.Fprintln(os.Stderr, color.RedString("No remote named \"origin\" found."))
-			fmt.Fprintln(os.Stderr, "Please make sure you have a remote named \"origin\".")
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get origin URL: %s", err.Error()))
+	branchName := options.Branch
+	commitName := "HEAD commit"
+	branchDescription := "current branch"
+	if branchName == "" {
+		var err error
+		branchName, err = repo.CurrentBranchName()
+		if err != nil {
+			if errors.Is(err, repository.ErrNoActiveBranch) {
+				openDetached(repo, options.Remote, print, copy)
+				return
+			} else {
+				fmt.Fprintln(os.Stderr, color.RedString("Unable to get current branch: %s", err.Error()))
+				os.Exit(1)
+			}
 		}
-		os.Exit(1)
+
+		fmt.Fprintf(os.Stderr, "Current branch: %s\n", color.GreenString(branchName))
+	} else {
+		commitName = "last commit of " + branchName
+		branchDescription = "branch " + branchName
+		fmt.Fprintf(os.Stderr, "Branch: %s\n", color.GreenString(branchName))
 	}
 
-	gitURL, err := giturl.Parse(originURL)
-	handleError(err, "Unable to parse origin URL")
+	branch, err := repo.ResolveBranch(branchName)
+	handleError(err, "Unable to read branch "+branchName)
 
-	branch, err := repo.CurrentBranchName()
-	if err != nil {
-		if errors.Is(err, repository.ErrNoActiveBranch) {
-			fmt.Fprintln(os.Stderr, color.RedString("No active branch found."))
-			fmt.Fprintln(os.Stderr, "Switch to a branch and try again.")
-			os.Exit(0)
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get current branch: %s", err.Error()))
-			os.Exit(1)
-		}
+	// Local branch may be pushed to branch with another name, e.g. "user/branch"
+	if branch.Local != "" && branch.Name != branch.Local {
+		fmt.Fprintf(os.Stderr, "Remote branch: %s\n", color.GreenString(branch.Name))
 	}
 
-	fmt.Fprintf(os.Stderr, "Current branch: %s\n", color.GreenString(branch))
+	remote, gitURL := selectBranchRemote(repo, options.Remote, branch)
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
 
-	if branch == "master" || branch == "main" || branch == "trunk" || branch == "develop" || branch == "dev" {
-		fmt.Fprintln(os.Stderr, "Looks like you are on the main branch. Opening home page.")
+	if matcher, ok := p.(provider.CommitMatcher); ok {
+		changeRequest, found := findCommitChangeRequest(matcher, p, repo, projectPath, branch.Hash, commitName)
+		if found {
+			showURL(changeRequest.URL, print, copy)
+			return
+		}
+
+		// Commits are submitted for review from any branch, main one included
+		instructions, url := matcher.SubmitInstructions(projectPath, remote.Name, submitBranch(repo, remote.Name, branch))
+		fmt.Fprintf(os.Stderr, "No %s found for %s.\n", p.Info().RequestName, commitName)
+		fmt.Fprintln(os.Stderr, instructions)
+		showURL(url, print, copy)
+		return
+	}
 
-		homeUrl := fmt.Sprintf("https://%s/%s", gitURL.Host, strings.TrimPrefix(gitURL.Path, "/"))
-		homeUrl = strings.TrimSuffix(homeUrl, ".git")
+	// Local name is checked, as a topic branch may be pushed under another name
+	mainName := branch.Name
+	if branch.Local != "" {
+		mainName = branch.Local
+	}
 
-		if print {
-			color.Blue(homeUrl)
-		} else if copy {
-			copyToClipboard(homeUrl)
-			fmt.Fprintln(os.Stderr, "Copied to clipboard: "+color.BlueString(homeUrl))
+	if isMainBranch(mainName) {
+		if options.Branch == "" {
+			fmt.Fprintln(os.Stderr, "Looks like you are on the main branch. Opening home page.")
 		} else {
-			color.Blue(homeUrl)
-			openBrowser(homeUrl)
+			fmt.Fprintf(os.Stderr, "Looks like %s is the main branch. Opening home page.\n", branchName)
 		}
 
+		showURL(p.HomeURL(projectPath), print, copy)
+
 		os.Exit(0)
 	}
 
-	projectPath := strings.TrimPrefix(gitURL.Path, "/")
-	projectPath = strings.TrimSuffix(projectPath, ".git")
+	// Change request is looked up before pushing, so nothing is pushed when
+	// the provider can't be accessed, e.g. without token
+	target := resolveTarget(repo, p, remote, gitURL, branch.Local, branch.Name)
+	changeRequest, exists := findChangeRequest(p, target)
 
-	var url string
-	var exists bool
-	var requestType string
-	switch gitURL.Host {
-	case "gitlab.com":
-		exists, url = getGitLabUrl(branch, projectPath)
-		requestType = "merge request"
-	case "github.com":
-		exists, url = getGitHubUrl(branch, projectPath)
-		requestType = "pull request"
-	default:
-		fmt.Fprintln(os.Stderr, "Unknown remote type")
-		os.Exit(1)
+	if branch.Local != "" {
+		checkPushed(repo, branch.Local, options.Push)
+	}
+
+	if exists {
+		showURL(changeRequest.URL, print, copy)
+		return
+	}
+
+	url := createChangeRequestUrl(p, repo, target, options.Push)
+	fmt.Fprintf(os.Stderr, "No open %s found for %s. Opening create page.\n", p.Info().RequestName, branchDescription)
+
+	showURL(url, print, copy)
+}
+
+// Returns branch commits of given branch are submitted to when change requests
+// are matched by commit: the branch it tracks, e.g. "master" for a topic
+// branch created from origin/master, or the default branch of the remote.
+func submitBranch(repo repository.Repository, remote string, branch repository.Branch) string {
+	// Remote-tracking branch is the target itself
+	if branch.Local == "" {
+		return branch.Name
+	}
+
+	tracked, err := repo.TrackedBranch(branch.Local)
+	handleError(err, "Unable to read git config")
+	if tracked != "" {
+		return tracked
+	}
+
+	defaultBranch, err := repo.DefaultBranch(remote)
+	handleError(err, "Unable to read default branch of "+remote)
+	if defaultBranch != "" {
+		return defaultBranch
+	}
+
+	return "<branch>"
+}
+
+// Warn if current branch has commits missing in its remote-tracking branch,
+// or push them if requested.
+func checkPushed(repo repository.Repository, branch string, push bool) {
+	tracking, err := repo.Tracking(branch)
+	if errors.Is(err, repository.ErrNoTrackingBranch) {
+		return
 	}
+	handleError(err, "Unable to compare branch with remote")
 
-	if !exists {
-		fmt.Fprintf(os.Stderr, "No open %s found for current branch. Opening create page.\n", requestType)
+	remoteBranch := tracking.Remote + "/" + tracking.Branch
+
+	if tracking.Behind > 0 {
+		fmt.Fprintf(os.Stderr, "Branch is %d commit(s) behind %s.\n", tracking.Behind, remoteBranch)
+	}
+
+	if tracking.Ahead == 0 {
+		return
 	}
 
+	if !push {
+		fmt.Fprintln(os.Stderr, color.YellowString("Branch is %d commit(s) ahead of %s, they are not pushed yet.", tracking.Ahead, remoteBranch))
+		fmt.Fprintln(os.Stderr, "Push the commits or run with --push flag to push them first.")
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Pushing %d commit(s) to %s\n", tracking.Ahead, remoteBranch)
+	err = repo.Push(tracking.Remote, branch, tracking.Branch, false)
+	handleError(err, "Unable to push")
+}
+
+func isMainBranch(branch string) bool {
+	return branch == "master" || branch == "main" || branch == "trunk" || branch == "develop" || branch == "dev"
+}
+
+// Print, copy or open given URL in browser.
+func showURL(url string, print bool, copy bool) {
 	if print {
 		color.Blue(url)
 	} else if copy {
@@ -110,88 +208,107 @@ func Open(repoPath string, print bool, copy bool) {
 	}
 }
 
-// Returns merge request URL if it exists for given branch, otherwise returns URL to create new one.
-func getGitLabUrl(branch string, projectPath string) (exists bool, url string) {
-	gitlabToken := config.Get().GitLabToken
-
-	if gitlabToken == "" {
-		color.Red("GitLab token is not set. Run `pro auth gitlab` to set it.")
-		os.Exit(1)
+// Returns change request matching commit with given hash, if there is one.
+// Commit name describes the commit in output, e.g. "HEAD commit".
+func findCommitChangeRequest(matcher provider.CommitMatcher, p provider.Provider, repo repository.Repository, projectPath string, hash string, commitName string) (changeRequest provider.ChangeRequest, found bool) {
+	// Branch existing only in the remote has no commit to match
+	if hash == "" {
+		return provider.ChangeRequest{}, false
 	}
 
-	mergeRequest, err := gitlab.FindMergeRequest(projectPath, gitlabToken, branch)
-	if err != nil {
-		if errors.Is(err, gitlab.ErrMergeRequestNotFound) {
-			branches, err := gitlab.GetRemoteBranches(projectPath, gitlabToken)
-			if err != nil {
-				fmt.Fprintln(os.Stderr, color.RedString("Unable to get branches: %s", err.Error()))
-				os.Exit(1)
-			}
+	subject, err := repo.CommitSubject(hash)
+	handleError(err, "Unable to read "+commitName)
 
-			// Check if the branch exists in the remote repository
-			for _, b := range branches {
-				if b == branch {
-					return false, fmt.Sprintf("https://gitlab.com/%s/merge_requests/new?merge_request%%5Bsource_branch%%5D=%s", projectPath, branch)
-				}
-			}
+	changeID, err := repo.CommitTrailer(hash, "Change-Id")
+	handleError(err, "Unable to read "+commitName)
 
-			fmt.Fprintln(os.Stderr, color.RedString("Branch \"%s\" not found in the remote repository. Push the branch to create a merge request.", branch))
-			os.Exit(1)
-		} else if errors.Is(err, gitlab.ErrProjectNotFound) {
-			fmt.Fprintln(os.Stderr, color.RedString("Project \"%s\" not found.", projectPath))
-			fmt.Fprintln(os.Stderr, "Maybe it was renamed or deleted? Change remote URL and try again.")
-			os.Exit(1)
-		} else if errors.Is(err, gitlab.ErrUnauthorized) || errors.Is(err, gitlab.ErrTokenExpired) {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get merge requests: %s", err.Error()))
-			fmt.Fprintln(os.Stderr, "Connect GitLab again with `pro auth gitlab`.")
-			os.Exit(1)
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get merge requests: %s", err.Error()))
-			os.Exit(1)
+	fmt.Fprintf(os.Stderr, "%s: %s\n", capitalize(commitName), color.GreenString(subject))
+
+	changeRequest, err = matcher.FindChangeRequestForCommit(projectPath, provider.Commit{Subject: subject, ChangeID: changeID})
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return provider.ChangeRequest{}, false
 		}
+		handleProviderError(err, p, projectPath, "get "+p.Info().RequestName+"s")
 	}
 
-	return true, mergeRequest.WebUrl
+	return changeRequest, true
 }
 
-// Returns pull request URL if it exists for given branch, otherwise returns URL to create new one.
-func getGitHubUrl(branch string, projectPath string) (exists bool, url string) {
-	githubToken := config.Get().GitHubToken
+// Returns URL to create new change request for given target. Branch missing
+// in the remote is pushed if requested.
+func createChangeRequestUrl(p provider.Provider, repo repository.Repository, target changeTarget, push bool) string {
+	requestName := p.Info().RequestName
+	forkProvider, isFork := p.(provider.ForkProvider)
+	isFork = isFork && target.isFork()
 
-	if githubToken == "" {
-		fmt.Fprintln(os.Stderr, color.RedString("GitHub token is not set. Run `pro auth github` to set it."))
+	// Check if the branch exists in the remote repository
+	branchExists, err := p.BranchExists(target.HeadPath, target.Branch)
+	if err != nil {
+		handleProviderError(err, p, target.HeadPath, "get branches")
+	}
+
+	if !branchExists && !pushMissingBranch(repo, target, push) {
+		fmt.Fprintln(os.Stderr, color.RedString("Branch \"%s\" not found in the remote repository. Push the branch to create a %s.", target.Branch, requestName))
+		if target.LocalBranch != "" {
+			fmt.Fprintln(os.Stderr, "Run with --push flag to push it and open the create page.")
+		}
 		os.Exit(1)
 	}
 
-	pullRequest, err := github.FindPullRequest(projectPath, githubToken, branch)
-	if err != nil {
-		if errors.Is(err, github.ErrNotFound) {
-			branches, err := github.GetRemoteBranches(projectPath, githubToken)
-			if err != nil {
-				fmt.Fprintln(os.Stderr, color.RedString("Unable to get branches: %s", err.Error()))
-				os.Exit(1)
-			}
+	if isFork {
+		url, err := forkProvider.ForkCreateURL(target.BasePath, target.HeadPath, target.Branch)
+		if err != nil {
+			handleProviderError(err, p, target.BasePath, "get repository")
+		}
 
-			// Check if the branch exists in the remote repository
-			for _, b := range branches {
-				if b == branch {
-					return false, fmt.Sprintf("https://github.com/%s/pull/new/%s", projectPath, branch)
-				}
-			}
+		return url
+	}
 
-			fmt.Fprintln(os.Stderr, color.RedString("Branch \"%s\" not found in the remote repository. Push the branch to create a pull request.", branch))
-			os.Exit(1)
-		} else if errors.Is(err, github.ErrUnauthorized) {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get pull requests: %s", err.Error()))
-			fmt.Fprintln(os.Stderr, "Token may be expired or deleted. Run `pro auth github` to connect GitHub again.")
-			os.Exit(1)
-		} else {
-			fmt.Fprintln(os.Stderr, color.RedString("Unable to get pull requests: %s", err.Error()))
-			os.Exit(1)
+	return p.CreateURL(target.BasePath, target.Branch)
+}
+
+// Returns open change request for given target, if there is one.
+func findChangeRequest(p provider.Provider, target changeTarget) (changeRequest provider.ChangeRequest, found bool) {
+	var err error
+	if forkProvider, ok := p.(provider.ForkProvider); ok && target.isFork() {
+		changeRequest, err = forkProvider.FindForkChangeRequest(target.BasePath, target.HeadPath, target.Branch)
+	} else {
+		changeRequest, err = p.FindChangeRequest(target.BasePath, target.Branch)
+	}
+
+	if err != nil {
+		if errors.Is(err, provider.ErrNotFound) {
+			return provider.ChangeRequest{}, false
 		}
+		handleProviderError(err, p, target.BasePath, "get "+p.Info().RequestName+"s")
+	}
+
+	return changeRequest, true
+}
+
+// Push branch missing in the remote with upstream tracking, if requested with
+// flag or confirmed by user when prompt_push is set in config. Returns false
+// if the branch was not pushed.
+func pushMissingBranch(repo repository.Repository, target changeTarget, push bool) bool {
+	// Only local branches can be pushed
+	if target.LocalBranch == "" {
+		return false
 	}
 
-	return true, pullRequest.HtmlURL
+	if !push && config.Get().PromptPush {
+		push = confirm(fmt.Sprintf("Branch \"%s\" is not pushed to %s. Push it now?", target.LocalBranch, target.HeadRemote))
+	}
+
+	if !push {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "Pushing branch %s to %s\n", target.LocalBranch, target.HeadRemote)
+	err := repo.Push(target.HeadRemote, target.LocalBranch, target.Branch, true)
+	handleError(err, "Unable to push")
+
+	return true
 }
 
 func openBrowser(url string) {
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wowu/pro/config"
+	"github.com/wowu/pro/giturl"
+	"github.com/wowu/pro/provider"
+	_ "github.com/wowu/pro/provider/azure"
+	_ "github.com/wowu/pro/provider/bitbucket"
+	_ "github.com/wowu/pro/provider/bitbucketserver"
+	_ "github.com/wowu/pro/provider/gerrit"
+	_ "github.com/wowu/pro/provider/gitea"
+	_ "github.com/wowu/pro/provider/github"
+	_ "github.com/wowu/pro/provider/gitlab"
+	_ "github.com/wowu/pro/provider/sourcehut"
+
+	"github.com/fatih/color"
+	"github.com/kevinburke/ssh_config"
+)
+
+// Return provider serving the host of given remote URL, exit if there is none.
+// Unknown hosts are looked up in SSH config, as they may be SSH host aliases.
+func providerForURL(gitURL *giturl.GitURL) provider.Provider {
+	conf := config.Get()
+
+	p, err := provider.ForHost(gitURL.Host, conf)
+	if errors.Is(err, provider.ErrUnknownHost) {
+		if hostname := sshHostName(gitURL.Host); hostname != gitURL.Host {
+			p, err = provider.ForHost(hostname, conf)
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, provider.ErrUnknownHost) {
+			fmt.Fprintln(os.Stderr, "Unknown remote type")
+			fmt.Fprintf(os.Stderr, "If %s is a self-hosted instance or an alias, declare it under \"hosts\" or \"aliases\" in the config file.\n", gitURL.Host)
+		} else {
+			fmt.Fprintln(os.Stderr, color.RedString("Unable to find provider: %s", err.Error()))
+		}
+		os.Exit(1)
+	}
+
+	return p
+}
+
+// Return real hostname of given SSH host alias from "HostName" entry of
+// ~/.ssh/config, or the alias itself if there is none.
+func sshHostName(alias string) string {
+	hostname := ssh_config.Get(alias, "HostName")
+	if hostname == "" {
+		return alias
+	}
+
+	// HostName may refer to the alias with %h token
+	return strings.ReplaceAll(hostname, "%h", alias)
+}
+
+// Return repository path without leading slash and ".git" suffix.
+func projectPathFromURL(gitURL *giturl.GitURL) string {
+	projectPath := strings.TrimPrefix(gitURL.Path, "/")
+	return strings.TrimSuffix(projectPath, ".git")
+}
+
+// Return command authorizing given provider instance.
+func authCommand(p provider.Provider) string {
+	name := p.Info().Name
+	if provider.IsWellKnownHost(name, p.Host()) {
+		return "pro auth " + name
+	}
+	return "pro auth " + name + " --host " + p.Host()
+}
+
+// Print explanation of provider API error and exit.
+// Action describes what failed, e.g. "get pull requests".
+func handleProviderError(err error, p provider.Provider, projectPath string, action string) {
+	info := p.Info()
+
+	if errors.Is(err, provider.ErrNoToken) {
+		fmt.Fprintln(os.Stderr, color.RedString("%s token is not set. Run `%s` to set it.", info.Title, authCommand(p)))
+	} else if errors.Is(err, provider.ErrUnauthorized) || errors.Is(err, provider.ErrTokenExpired) {
+		fmt.Fprintln(os.Stderr, color.RedString("Unable to %s: %s", action, err.Error()))
+		fmt.Fprintf(os.Stderr, "Token may be expired or deleted. Run `%s` to connect %s again.\n", authCommand(p), info.Title)
+	} else if errors.Is(err, provider.ErrProjectNotFound) {
+		fmt.Fprintln(os.Stderr, color.RedString("Project \"%s\" not found.", projectPath))
+		fmt.Fprintln(os.Stderr, "Maybe it was renamed or deleted? Change remote URL and try again.")
+	} else {
+		fmt.Fprintln(os.Stderr, color.RedString("Unable to %s: %s", action, err.Error()))
+	}
+
+	os.Exit(1)
+}
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/wowu/pro/giturl"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Return git repository in given directory or its parents, exit if there is
+// none. With superproject, the repository the found one is a submodule of is
+// returned instead.
+func findRepository(repoPath string, superproject bool) repository.Repository {
+	repo, err := repository.FindInParents(repoPath)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRepository) {
+			fmt.Fprintln(os.Stderr, color.RedString("Unable to find git repository in given directory or any of parent directories."))
+			fmt.Fprintln(os.Stderr, "Please make sure you are in the project directory.")
+		} else {
+			fmt.Fprintln(os.Stderr, color.RedString("Unable to open git repository: %s", err.Error()))
+		}
+		os.Exit(1)
+	}
+
+	if !superproject {
+		return repo
+	}
+
+	parent, err := repo.Superproject()
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuperproject) {
+			fmt.Fprintln(os.Stderr, color.RedString("Repository is not a submodule of another repository."))
+			fmt.Fprintln(os.Stderr, "Run without --superproject flag.")
+		} else {
+			fmt.Fprintln(os.Stderr, color.RedString("Unable to find superproject: %s", err.Error()))
+		}
+		os.Exit(1)
+	}
+
+	root, err := parent.Root()
+	handleError(err, "Unable to open superproject")
+	fmt.Fprintf(os.Stderr, "Superproject: %s\n", color.GreenString(root))
+
+	return parent
+}
+
+// Return remote selected for given branch and its parsed URL, exit if there is
+// none. Remote flag may be empty or "auto" to select the remote automatically.
+func selectRemote(repo repository.Repository, remoteFlag string, branch string) (repository.Remote, *giturl.GitURL) {
+	remote, err := repo.SelectRemote(remoteFlag, branch)
+	handleError(err, "Unable to read git config")
+
+	return remote, remoteURL(repo, remote)
+}
+
+// Return remote selected for current branch, or for no branch when HEAD is
+// detached, and its parsed URL.
+func selectCurrentRemote(repo repository.Repository, remoteFlag string) (repository.Remote, *giturl.GitURL) {
+	branch, err := repo.CurrentBranchName()
+	if err != nil && !errors.Is(err, repository.ErrNoActiveBranch) {
+		handleError(err, "Unable to get current branch")
+	}
+
+	return selectRemote(repo, remoteFlag, branch)
+}
+
+// Return remote selected for branch named by user and its parsed URL. Remote of
+// a remote-tracking branch is used, unless other one is chosen with the flag.
+func selectBranchRemote(repo repository.Repository, remoteFlag string, branch repository.Branch) (repository.Remote, *giturl.GitURL) {
+	if branch.Remote != "" && (remoteFlag == "" || remoteFlag == "auto") {
+		remote := repository.Remote{Name: branch.Remote, Reason: "remote-tracking branch"}
+		return remote, remoteURL(repo, remote)
+	}
+
+	return selectRemote(repo, remoteFlag, branch.Local)
+}
+
+// Return parsed URL of given remote, exit if there is no such remote.
+func remoteURL(repo repository.Repository, remote repository.Remote) *giturl.GitURL {
+	fmt.Fprintf(os.Stderr, "Remote: %s (%s)\n", color.GreenString(remote.Name), remote.Reason)
+
+	rawURL, err := repo.RemoteURL(remote.Name)
+	if err != nil {
+		if errors.Is(err, repository.ErrRemoteNotFound) {
+			fmt.Fprintln(os.Stderr, color.RedString("No remote named \"%s\" found.", remote.Name))
+			fmt.Fprintln(os.Stderr, "Add the remote or choose another one with --remote flag.")
+		} else {
+			fmt.Fprintln(os.Stderr, color.RedString("Unable to get %s URL: %s", remote.Name, err.Error()))
+		}
+		os.Exit(1)
+	}
+
+	gitURL, err := giturl.Parse(rawURL)
+	handleError(err, "Unable to parse "+remote.Name+" URL")
+
+	return gitURL
+}
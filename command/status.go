@@ -0,0 +1,210 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/wowu/pro/provider"
+	"github.com/wowu/pro/repository"
+
+	"github.com/fatih/color"
+)
+
+// Options of `pro status`.
+type StatusOptions struct {
+	// Remote to use, empty or "auto" to select it automatically.
+	Remote string
+
+	// Print status as JSON.
+	JSON bool
+
+	// Use the repository current one is a submodule of.
+	Superproject bool
+}
+
+// Status of change request printed by `pro status --json`. Fields unknown to
+// the provider are empty.
+type statusOutput struct {
+	Number       int             `json:"number"`
+	Title        string          `json:"title"`
+	URL          string          `json:"url"`
+	State        string          `json:"state"`
+	Draft        bool            `json:"draft"`
+	Author       string          `json:"author"`
+	SourceBranch string          `json:"source_branch"`
+	TargetBranch string          `json:"target_branch"`
+	Review       string          `json:"review"`
+	Approvals    int             `json:"approvals"`
+	Mergeable    string          `json:"mergeable"`
+	CI           *pipelineOutput `json:"ci"`
+}
+
+type pipelineOutput struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	URL    string `json:"url"`
+}
+
+func newStatusOutput(status provider.ChangeRequestStatus) statusOutput {
+	output := statusOutput{
+		Number:       status.Number,
+		Title:        status.Title,
+		URL:          status.URL,
+		State:        status.State,
+		Draft:        status.Draft,
+		Author:       status.Author,
+		SourceBranch: status.Branch,
+		TargetBranch: status.TargetBranch,
+		Review:       status.Review,
+		Approvals:    status.Approvals,
+		Mergeable:    status.Mergeable,
+	}
+
+	if status.Pipeline != nil {
+		output.CI = &pipelineOutput{
+			Name:   status.Pipeline.Name,
+			Status: status.Pipeline.Status,
+			URL:    status.Pipeline.URL,
+		}
+	}
+
+	return output
+}
+
+// Print status of the open change request of current branch: its state,
+// review, mergeability and CI. Providers not reporting details show only
+// title and state.
+func Status(repoPath string, options StatusOptions) {
+	repo := findRepository(repoPath, options.Superproject)
+
+	branchName, err := repo.CurrentBranchName()
+	if errors.Is(err, repository.ErrNoActiveBranch) {
+		fmt.Fprintln(os.Stderr, color.RedString("Not on a branch, check out the branch of a change request first."))
+		os.Exit(1)
+	}
+	handleError(err, "Unable to get current branch")
+
+	fmt.Fprintf(os.Stderr, "Current branch: %s\n", color.GreenString(branchName))
+
+	branch, err := repo.ResolveBranch(branchName)
+	handleError(err, "Unable to read branch "+branchName)
+
+	remote, gitURL := selectBranchRemote(repo, options.Remote, branch)
+	p := providerForURL(gitURL)
+	projectPath := projectPathFromURL(gitURL)
+	info := p.Info()
+
+	var changeRequest provider.ChangeRequest
+	var found bool
+	description := "current branch"
+	if matcher, ok := p.(provider.CommitMatcher); ok {
+		// Change requests are matched by commit, as for `pro open`
+		changeRequest, found = findCommitChangeRequest(matcher, p, repo, projectPath, branch.Hash, "HEAD commit")
+		description = "HEAD commit"
+	} else {
+		target := resolveTarget(repo, p, remote, gitURL, branch.Local, branch.Name)
+		projectPath = target.BasePath
+		changeRequest, found = findChangeRequest(p, target)
+	}
+
+	if !found {
+		fmt.Fprintf(os.Stderr, "No open %s found for %s.\n", info.RequestName, description)
+		os.Exit(1)
+	}
+
+	status := provider.ChangeRequestStatus{ChangeRequest: changeRequest}
+	if statusProvider, ok := p.(provider.StatusProvider); ok {
+		status, err = statusProvider.ChangeRequestStatus(projectPath, changeRequest.Number)
+		if err != nil {
+			handleProviderError(err, p, projectPath, "get "+info.RequestName)
+		}
+	}
+
+	if options.JSON {
+		output, err := json.MarshalIndent(newStatusOutput(status), "", "  ")
+		handleError(err, "Unable to encode status")
+		fmt.Println(string(output))
+		return
+	}
+
+	printStatus(info, status)
+}
+
+func printStatus(info provider.Info, status provider.ChangeRequestStatus) {
+	fmt.Printf("%s %s\n", color.New(color.Bold).Sprint(status.Title), color.HiBlackString("%s%d", info.RefPrefix, status.Number))
+
+	state := stateString(status.State)
+	if status.Draft {
+		state += ", draft"
+	}
+	printStatusLine("State", state)
+	printStatusLine("Author", status.Author)
+
+	if status.TargetBranch != "" {
+		printStatusLine("Branch", status.Branch+" → "+status.TargetBranch)
+	} else {
+		printStatusLine("Branch", status.Branch)
+	}
+
+	if status.Review != "" {
+		review := reviewString(status.Review)
+		if status.Approvals > 0 {
+			review += fmt.Sprintf(", %d approval(s)", status.Approvals)
+		}
+		printStatusLine("Review", review)
+	}
+
+	// Merge state is meaningless once the change request is merged or closed
+	if status.State == provider.StateOpen {
+		printStatusLine("Merge", mergeableString(status.Mergeable))
+	}
+
+	if status.Pipeline != nil {
+		printStatusLine("CI", fmt.Sprintf("%s (%s)", status.Pipeline.Name, pipelineStatusString(status.Pipeline.Status)))
+	}
+
+	printStatusLine("URL", color.BlueString(status.URL))
+}
+
+// Print labeled line of status, skipping unknown values.
+func printStatusLine(label string, value string) {
+	if value == "" {
+		return
+	}
+
+	fmt.Printf("%-8s %s\n", label+":", value)
+}
+
+// Return review state in words, colored like on hosting sites.
+func reviewString(review string) string {
+	switch review {
+	case provider.ReviewApproved:
+		return color.GreenString("approved")
+	case provider.ReviewChangesRequested:
+		return color.RedString("changes requested")
+	case provider.ReviewRequired:
+		return color.YellowString("review required")
+	case provider.ReviewNone:
+		return "no reviews"
+	default:
+		return review
+	}
+}
+
+// Return merge state in words, colored like on hosting sites.
+func mergeableString(mergeable string) string {
+	switch mergeable {
+	case provider.MergeableYes:
+		return color.GreenString("ready to merge")
+	case provider.MergeableConflicts:
+		return color.RedString("has conflicts")
+	case provider.MergeableBlocked:
+		return color.YellowString("blocked")
+	case provider.MergeableUnknown:
+		return "not checked yet"
+	default:
+		return mergeable
+	}
+}
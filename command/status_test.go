@@ -0,0 +1,63 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/wowu/pro/provider"
+)
+
+func TestStatusOutput(t *testing.T) {
+	status := provider.ChangeRequestStatus{
+		ChangeRequest: provider.ChangeRequest{
+			Number: 12,
+			Title:  "Add status command",
+			Branch: "status",
+			URL:    "https://github.com/wowu/pro/pull/12",
+			State:  provider.StateOpen,
+		},
+		Draft:        true,
+		Author:       "jdoe",
+		TargetBranch: "main",
+		Review:       provider.ReviewApproved,
+		Approvals:    2,
+		Mergeable:    provider.MergeableConflicts,
+		Pipeline: &provider.Pipeline{
+			Name:   "build",
+			Status: provider.PipelineFailed,
+			URL:    "https://github.com/wowu/pro/actions/runs/1",
+		},
+	}
+
+	got, err := json.Marshal(newStatusOutput(status))
+	if err != nil {
+		t.Fatalf("json.Marshal() returned unexpected error: %v", err)
+	}
+
+	want := `{"number":12,"title":"Add status command","url":"https://github.com/wowu/pro/pull/12","state":"open","draft":true,` +
+		`"author":"jdoe","source_branch":"status","target_branch":"main","review":"approved","approvals":2,"mergeable":"conflicts",` +
+		`"ci":{"name":"build","status":"failed","url":"https://github.com/wowu/pro/actions/runs/1"}}`
+	if string(got) != want {
+		t.Errorf("newStatusOutput() JSON = %s, want %s", got, want)
+	}
+}
+
+func TestStatusOutputWithoutPipeline(t *testing.T) {
+	status := provider.ChangeRequestStatus{
+		ChangeRequest: provider.ChangeRequest{Number: 3, State: provider.StateMerged},
+	}
+
+	got, err := json.Marshal(newStatusOutput(status))
+	if err != nil {
+		t.Fatalf("json.Marshal() returned unexpected error: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(got, &fields); err != nil {
+		t.Fatalf("json.Unmarshal() returned unexpected error: %v", err)
+	}
+
+	if ci, ok := fields["ci"]; !ok || ci != nil {
+		t.Errorf("ci = %v, want null", ci)
+	}
+}
@@ -1,8 +1,10 @@
 package command
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // Print error and exit if error is present.
@@ -17,3 +19,25 @@ func handleError(err error, reason string) {
 		os.Exit(1)
 	}
 }
+
+// Ask user a yes/no question, answer defaults to yes.
+func confirm(question string) bool {
+	fmt.Fprint(os.Stderr, question+" [Y/n] ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// Return string with first letter in upper case, e.g. "Pull request".
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
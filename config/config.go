@@ -10,8 +10,100 @@ import (
 )
 
 type Config struct {
-	GitHubToken string `yaml:"github_token"`
-	GitLabToken string `yaml:"gitlab_token"`
+	GitHubToken    string           `yaml:"github_token"`
+	GitLabToken    string           `yaml:"gitlab_token"`
+	BitbucketToken string           `yaml:"bitbucket_token,omitempty"`
+	GiteaToken     string           `yaml:"gitea_token,omitempty"`
+	AzureToken     string           `yaml:"azure_token,omitempty"`
+	SourceHutToken string           `yaml:"sourcehut_token,omitempty"`
+	Hosts          map[string]Host  `yaml:"hosts,omitempty"`
+	Aliases        map[string]Alias `yaml:"aliases,omitempty"`
+
+	// PromptPush asks whether to push current branch when it's missing in
+	// the remote, instead of requiring --push flag.
+	PromptPush bool `yaml:"prompt_push,omitempty"`
+}
+
+// Host configures a self-hosted provider instance, keyed by hostname in Config.Hosts.
+type Host struct {
+	// Provider is the name of the provider running on the host, e.g. "gitlab".
+	Provider string `yaml:"provider"`
+
+	// APIURL is the base URL of the API, e.g. "https://gitlab.example.com/api/v4".
+	// Derived from the hostname when empty.
+	APIURL string `yaml:"api_url,omitempty"`
+
+	// WebURL is the base URL of the web interface, e.g. "https://gitlab.example.com".
+	// Derived from the hostname when empty.
+	WebURL string `yaml:"web_url,omitempty"`
+
+	Token string `yaml:"token,omitempty"`
+}
+
+// Alias maps a host alias found in remote URLs, e.g. SSH config alias
+// "gh-work" or a mirror, to the real host, keyed by alias in Config.Aliases.
+type Alias struct {
+	Host string `yaml:"host"`
+
+	// Provider running on the real host. Needed only if the host is neither
+	// well-known nor declared in Config.Hosts.
+	Provider string `yaml:"provider,omitempty"`
+}
+
+// Token returns the token saved for given provider. Hosts configured in
+// Config.Hosts have their own tokens.
+func (c Config) Token(provider string, host string) string {
+	if h, ok := c.Hosts[host]; ok {
+		return h.Token
+	}
+
+	switch provider {
+	case "github":
+		return c.GitHubToken
+	case "gitlab":
+		return c.GitLabToken
+	case "bitbucket":
+		return c.BitbucketToken
+	case "gitea":
+		return c.GiteaToken
+	case "azure":
+		return c.AzureToken
+	case "sourcehut":
+		return c.SourceHutToken
+	default:
+		return ""
+	}
+}
+
+// SetToken saves token for given provider. If host is not empty, the token is
+// saved for that host only.
+func (c *Config) SetToken(provider string, host string, token string) {
+	if host != "" {
+		if c.Hosts == nil {
+			c.Hosts = map[string]Host{}
+		}
+
+		h := c.Hosts[host]
+		h.Provider = provider
+		h.Token = token
+		c.Hosts[host] = h
+		return
+	}
+
+	switch provider {
+	case "github":
+		c.GitHubToken = token
+	case "gitlab":
+		c.GitLabToken = token
+	case "bitbucket":
+		c.BitbucketToken = token
+	case "gitea":
+		c.GiteaToken = token
+	case "azure":
+		c.AzureToken = token
+	case "sourcehut":
+		c.SourceHutToken = token
+	}
 }
 
 // Read config file and return config object.
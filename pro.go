@@ -3,12 +3,27 @@ package main
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/wowu/pro/command"
+	"github.com/wowu/pro/provider"
 
 	"github.com/urfave/cli/v2"
 )
 
+var remoteFlag = &cli.StringFlag{
+	Name:    "remote",
+	Aliases: []string{"r"},
+	Usage:   "git remote to use, \"auto\" prefers remote of current branch, then upstream, then origin",
+	Value:   "auto",
+}
+
+var superprojectFlag = &cli.BoolFlag{
+	Name:  "superproject",
+	Usage: "use the repository current one is a submodule of",
+}
+
 var openCommandFlags = []cli.Flag{
 	&cli.BoolFlag{
 		Name:    "print",
@@ -20,6 +35,79 @@ var openCommandFlags = []cli.Flag{
 		Aliases: []string{"c"},
 		Usage:   "copy URL to clipboard instead of opening in browser",
 	},
+	remoteFlag,
+	superprojectFlag,
+}
+
+// Flags of `pro open`, which is also the default action.
+var openFlags = append([]cli.Flag{
+	&cli.BoolFlag{
+		Name:  "push",
+		Usage: "push unpushed commits of current branch before opening",
+	},
+	&cli.StringFlag{
+		Name:    "branch",
+		Aliases: []string{"b"},
+		Usage:   "open PR of given local or remote branch instead of current one",
+	},
+}, openCommandFlags...)
+
+// Returns shell completion of `pro open`, which completes branch names unless
+// a flag is being completed. Values of given flags are completed as branch
+// names too, "--branch" and "-b" by default.
+func completeOpen(defaultComplete cli.BashCompleteFunc, completeArgs bool, branchFlags ...string) cli.BashCompleteFunc {
+	if len(branchFlags) == 0 {
+		branchFlags = []string{"--branch", "-b"}
+	}
+
+	return func(c *cli.Context) {
+		// Word being completed is passed last when it starts with "-",
+		// otherwise the last argument is the word before it
+		var lastArg string
+		if len(os.Args) > 2 {
+			lastArg = os.Args[len(os.Args)-2]
+		}
+
+		if slices.Contains(branchFlags, lastArg) || (completeArgs && !strings.HasPrefix(lastArg, "-")) {
+			command.CompleteBranches(".")
+			return
+		}
+
+		defaultComplete(c)
+	}
+}
+
+// Returns `pro auth <provider>` subcommand for every provider.
+func authCommands() []*cli.Command {
+	var commands []*cli.Command
+	for _, info := range provider.Infos() {
+		commands = append(commands, &cli.Command{
+			Name:  info.Name,
+			Usage: "Authorize " + info.Title,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "host",
+					Usage: "hostname of a self-hosted instance",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				command.Auth(c.Command.Name, c.String("host"))
+				return nil
+			},
+		})
+	}
+	return commands
+}
+
+func openOptions(c *cli.Context) command.OpenOptions {
+	return command.OpenOptions{
+		Remote:       c.String("remote"),
+		Print:        c.Bool("print"),
+		Copy:         c.Bool("copy"),
+		Push:         c.Bool("push"),
+		Superproject: c.Bool("superproject"),
+		Branch:       c.String("branch"),
+	}
 }
 
 func main() {
@@ -29,37 +117,149 @@ func main() {
 		Name:    "pro",
 		Usage:   "Pull Request Opener",
 		Version: "v0.6.4",
-		Flags:   openCommandFlags,
+		Flags:   openFlags,
+
+		EnableBashCompletion: true,
+		BashComplete:         completeOpen(cli.DefaultAppComplete, false),
 		Commands: []*cli.Command{
 			{
-				Name:      "auth",
-				ArgsUsage: "[gitlab|github]",
-				Usage:     "Authorize GitLab or GitHub",
-				UsageText: "pro auth gitlab\npro login github",
+				Name:        "auth",
+				ArgsUsage:   "[" + strings.Join(provider.Names(), "|") + "]",
+				Usage:       "Authorize access to a git hosting provider",
+				UsageText:   "pro auth gitlab\npro auth gitlab --host gitlab.example.com",
+				Subcommands: authCommands(),
 				Action: func(c *cli.Context) error {
-					if c.NArg() != 1 {
-						fmt.Println("Please specify provider (github or gitlab)")
-						os.Exit(1)
-					}
-
-					provider := c.Args().Get(0)
+					command.Auth(c.Args().Get(0), "")
 
-					if provider != "gitlab" && provider != "github" {
-						fmt.Println("Please specify provider (github or gitlab)")
-						os.Exit(1)
+					return nil
+				},
+			},
+			{
+				Name:      "open",
+				Usage:     "Open PR page in browser (default action)",
+				ArgsUsage: "[branch | number]",
+				UsageText: "pro open\npro open feature-x\npro open origin/feature-x\npro open 1234\npro open '#1234'",
+				Flags:     openFlags,
+				BashComplete: func(c *cli.Context) {
+					completeOpen(cli.DefaultCompleteWithFlags(c.Command), true)(c)
+				},
+				Action: func(c *cli.Context) error {
+					// Argument is a change request number like "#1234" or "!56",
+					// or a branch name
+					options := openOptions(c)
+					if arg := c.Args().First(); arg != "" && options.Branch == "" {
+						if number, ok := command.ParseChangeRequestRef(arg); ok {
+							options.Number = number
+						} else {
+							options.Branch = arg
+						}
 					}
 
-					command.Auth(provider)
-
+					command.Open(".", options)
 					return nil
 				},
 			},
 			{
-				Name:  "open",
-				Usage: "Open PR page in browser (default action)",
+				Name:      "browse",
+				Usage:     "Open file, commit or tag in browser, pinned to current commit",
+				ArgsUsage: "<path>[:line[-line]]",
+				UsageText: "pro browse README.md\npro browse main.go:10-20\npro browse --branch main.go:10\npro browse --at-branch main main.go:10\npro browse --commit HEAD~2\npro browse --tag v1.0",
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:    "branch",
+						Aliases: []string{"b"},
+						Usage:   "show file at current branch instead of current commit",
+					},
+					&cli.StringFlag{
+						Name:  "at-branch",
+						Usage: "show file at given branch instead of current commit",
+					},
+					&cli.StringFlag{
+						Name:  "commit",
+						Usage: "show given commit, or file at the commit",
+					},
+					&cli.StringFlag{
+						Name:  "tag",
+						Usage: "show given tag, or file at the tag",
+					},
+				}, openCommandFlags...),
+				BashComplete: func(c *cli.Context) {
+					completeOpen(cli.DefaultCompleteWithFlags(c.Command), false, "--at-branch")(c)
+				},
+				Action: func(c *cli.Context) error {
+					command.Browse(".", c.Args().First(), command.BrowseOptions{
+						Remote:        c.String("remote"),
+						Print:         c.Bool("print"),
+						Copy:          c.Bool("copy"),
+						Superproject:  c.Bool("superproject"),
+						CurrentBranch: c.Bool("branch"),
+						Branch:        c.String("at-branch"),
+						Commit:        c.String("commit"),
+						Tag:           c.String("tag"),
+					})
+					return nil
+				},
+			},
+			{
+				Name:      "compare",
+				Usage:     "Open page comparing two revisions in browser",
+				ArgsUsage: "<base>..<head>",
+				UsageText: "pro compare main..feature-x\npro compare v1.0..v1.1\npro compare main..",
+				Flags:     openCommandFlags,
+				BashComplete: func(c *cli.Context) {
+					completeOpen(cli.DefaultCompleteWithFlags(c.Command), true)(c)
+				},
+				Action: func(c *cli.Context) error {
+					command.Compare(".", c.Args().First(), command.CompareOptions{
+						Remote:       c.String("remote"),
+						Print:        c.Bool("print"),
+						Copy:         c.Bool("copy"),
+						Superproject: c.Bool("superproject"),
+					})
+					return nil
+				},
+			},
+			{
+				Name:  "ci",
+				Usage: "Open the latest CI pipeline of current commit in browser",
 				Flags: openCommandFlags,
 				Action: func(c *cli.Context) error {
-					command.Open(".", c.Bool("print"), c.Bool("copy"))
+					command.CI(".", command.CIOptions{
+						Remote:       c.String("remote"),
+						Print:        c.Bool("print"),
+						Copy:         c.Bool("copy"),
+						Superproject: c.Bool("superproject"),
+					})
+					return nil
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "Show state, reviews and CI of Pull Request of current branch",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print status as JSON",
+					},
+					remoteFlag,
+					superprojectFlag,
+				},
+				Action: func(c *cli.Context) error {
+					command.Status(".", command.StatusOptions{
+						Remote:       c.String("remote"),
+						JSON:         c.Bool("json"),
+						Superproject: c.Bool("superproject"),
+					})
+					return nil
+				},
+			},
+			{
+				Name:      "completion",
+				Usage:     "Print shell completion script",
+				ArgsUsage: "bash|zsh",
+				UsageText: "source <(pro completion bash)",
+				Action: func(c *cli.Context) error {
+					command.Completion(c.Args().First())
 					return nil
 				},
 			},
@@ -69,7 +269,12 @@ func main() {
 				Usage:   "Interactive Pull Request browser. Select to open in browser.",
 				Flags:   openCommandFlags,
 				Action: func(c *cli.Context) error {
-					command.List(".", c.Bool("print"), c.Bool("copy"))
+					command.List(".", command.ListOptions{
+						Remote:       c.String("remote"),
+						Print:        c.Bool("print"),
+						Copy:         c.Bool("copy"),
+						Superproject: c.Bool("superproject"),
+					})
 					return nil
 				},
 			},
@@ -81,7 +286,7 @@ func main() {
 				return nil
 			}
 
-			command.Open(".", c.Bool("print"), c.Bool("copy"))
+			command.Open(".", openOptions(c))
 
 			return nil
 		},
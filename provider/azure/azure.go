@@ -0,0 +1,411 @@
+package azure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+)
+
+func init() {
+	provider.Register(provider.Registration{
+		Info:  info,
+		Hosts: []string{"dev.azure.com", "ssh.dev.azure.com"},
+		New:   New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "azure",
+	Title:       "Azure DevOps",
+	RequestName: "pull request",
+	RefPrefix:   "!",
+	TokenScopes: "Code (Read)",
+	TokenHint:   "Select \"All accessible organizations\" to use the token with every organization.",
+}
+
+const apiVersion = "7.1"
+
+var ErrInvalidProjectPath = errors.New("unable to find organization, project and repository in remote URL")
+
+type Azure struct {
+	host   string
+	webURL string
+	token  string
+}
+
+// New returns Azure DevOps provider for given instance. HTTPS remotes are
+// served from dev.azure.com and SSH remotes from ssh.dev.azure.com, but both
+// use https://dev.azure.com for API and web pages.
+func New(instance provider.Instance) provider.Provider {
+	webURL := strings.TrimSuffix(instance.WebURL, "/")
+	if webURL == "" {
+		webURL = "https://dev.azure.com"
+	}
+
+	return &Azure{host: instance.Host, webURL: webURL, token: instance.Token}
+}
+
+// Repository identifies a Git repository in Azure DevOps.
+type Repository struct {
+	Organization string
+	Project      string
+	Name         string
+}
+
+// ParseProjectPath decodes organization, project and repository from the
+// path of a remote URL. Supported forms are "org/project/_git/repo" used by
+// HTTPS remotes and "v3/org/project/repo" used by SSH remotes.
+func ParseProjectPath(projectPath string) (Repository, error) {
+	// Project and repository names may contain spaces, which are
+	// percent-encoded in SSH remote URLs.
+	unescaped, err := url.PathUnescape(projectPath)
+	if err == nil {
+		projectPath = unescaped
+	}
+
+	parts := strings.Split(strings.Trim(projectPath, "/"), "/")
+
+	switch {
+	case len(parts) == 4 && parts[0] == "v3":
+		return Repository{Organization: parts[1], Project: parts[2], Name: parts[3]}, nil
+	case len(parts) == 4 && parts[2] == "_git":
+		return Repository{Organization: parts[0], Project: parts[1], Name: parts[3]}, nil
+	default:
+		return Repository{}, ErrInvalidProjectPath
+	}
+}
+
+// Returns URL of the repository relative to the base URL, with escaped path segments.
+func (r Repository) path() string {
+	return url.PathEscape(r.Organization) + "/" + url.PathEscape(r.Project) + "/_git/" + url.PathEscape(r.Name)
+}
+
+// Returns API URL of the repository relative to the base URL.
+func (r Repository) apiPath() string {
+	return url.PathEscape(r.Organization) + "/" + url.PathEscape(r.Project) + "/_apis/git/repositories/" + url.PathEscape(r.Name)
+}
+
+type ApiResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+func apiGet(url string, token string) (ApiResponse, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	// Personal access tokens are sent as password with empty username
+	req.SetBasicAuth("", token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	return ApiResponse{resp.StatusCode, body}, nil
+}
+
+func (a *Azure) get(url string) (ApiResponse, error) {
+	if a.token == "" {
+		return ApiResponse{}, provider.ErrNoToken
+	}
+
+	return apiGet(url, a.token)
+}
+
+func unknownResponseError(resp ApiResponse) error {
+	return errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+}
+
+func (a *Azure) Info() provider.Info {
+	return info
+}
+
+func (a *Azure) Host() string {
+	return a.host
+}
+
+func (a *Azure) TokenURL() string {
+	return a.webURL + "/_usersSettings/tokens"
+}
+
+// https://learn.microsoft.com/en-us/rest/api/azure/devops/profile/profiles/get
+func (a *Azure) ValidateToken(token string) error {
+	resp, err := apiGet("https://app.vssps.visualstudio.com/_apis/profile/profiles/me?api-version="+apiVersion, token)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	// Invalid tokens are sometimes answered with a sign-in page instead of 401
+	case http.StatusUnauthorized, http.StatusNonAuthoritativeInfo:
+		return provider.ErrUnauthorized
+	case http.StatusOK:
+		return nil
+	default:
+		return unknownResponseError(resp)
+	}
+}
+
+type PullRequestResponse struct {
+	PullRequestID int    `json:"pullRequestId"`
+	Title         string `json:"title"`
+	Status        string `json:"status"`
+	SourceRefName string `json:"sourceRefName"`
+}
+
+func (a *Azure) changeRequest(repo Repository, pr PullRequestResponse) provider.ChangeRequest {
+	state := provider.StateOpen
+	switch pr.Status {
+	case "completed":
+		state = provider.StateMerged
+	case "abandoned":
+		state = provider.StateClosed
+	}
+
+	return provider.ChangeRequest{
+		Number: pr.PullRequestID,
+		Title:  pr.Title,
+		Branch: strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+		URL:    fmt.Sprintf("%s/%s/pullrequest/%d", a.webURL, repo.path(), pr.PullRequestID),
+		State:  state,
+	}
+}
+
+// https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pull-requests/get-pull-requests
+func (a *Azure) listPullRequests(repo Repository, branch string) ([]PullRequestResponse, error) {
+	requestURL := a.webURL + "/" + repo.apiPath() + "/pullrequests?searchCriteria.status=active&api-version=" + apiVersion
+	if branch != "" {
+		requestURL += "&searchCriteria.sourceRefName=" + url.QueryEscape("refs/heads/"+branch)
+	}
+
+	resp, err := a.get(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusNonAuthoritativeInfo:
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var list struct {
+			Value []PullRequestResponse `json:"value"`
+		}
+		err = json.Unmarshal(resp.Body, &list)
+		if err != nil {
+			return nil, err
+		}
+
+		return list.Value, nil
+	default:
+		return nil, unknownResponseError(resp)
+	}
+}
+
+func (a *Azure) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	pullRequests, err := a.listPullRequests(repo, branch)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	if len(pullRequests) == 0 {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	return a.changeRequest(repo, pullRequests[0]), nil
+}
+
+// https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pull-requests/get-pull-request
+func (a *Azure) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	resp, err := a.get(a.webURL + "/" + repo.apiPath() + "/pullrequests/" + fmt.Sprint(number) + "?api-version=" + apiVersion)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusNonAuthoritativeInfo:
+		return provider.ChangeRequest{}, provider.ErrUnauthorized
+	// Same status is returned for missing repositories
+	case http.StatusNotFound:
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	case http.StatusOK:
+		var pullRequest PullRequestResponse
+		err = json.Unmarshal(resp.Body, &pullRequest)
+		if err != nil {
+			return provider.ChangeRequest{}, err
+		}
+
+		return a.changeRequest(repo, pullRequest), nil
+	default:
+		return provider.ChangeRequest{}, unknownResponseError(resp)
+	}
+}
+
+func (a *Azure) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pullRequests, err := a.listPullRequests(repo, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var changeRequests []provider.ChangeRequest
+	for _, pr := range pullRequests {
+		changeRequests = append(changeRequests, a.changeRequest(repo, pr))
+	}
+
+	return changeRequests, nil
+}
+
+// https://learn.microsoft.com/en-us/rest/api/azure/devops/git/refs/list
+func (a *Azure) BranchExists(projectPath string, branch string) (bool, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return false, err
+	}
+
+	url := a.webURL + "/" + repo.apiPath() + "/refs?filter=" + url.QueryEscape("heads/"+branch) + "&api-version=" + apiVersion
+	resp, err := a.get(url)
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusNonAuthoritativeInfo:
+		return false, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return false, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var list struct {
+			Value []struct {
+				Name string `json:"name"`
+			} `json:"value"`
+		}
+		err = json.Unmarshal(resp.Body, &list)
+		if err != nil {
+			return false, err
+		}
+
+		// Filter matches by prefix, so "feature" also returns "feature-2"
+		for _, ref := range list.Value {
+			if ref.Name == "refs/heads/"+branch {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	default:
+		return false, unknownResponseError(resp)
+	}
+}
+
+func (a *Azure) CreateURL(projectPath string, branch string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return a.webURL
+	}
+
+	return fmt.Sprintf("%s/%s/pullrequestcreate?sourceRef=%s", a.webURL, repo.path(), url.QueryEscape(branch))
+}
+
+// Returns revision as version parameter of web pages, prefixed with its kind:
+// "GC" for commits, "GB" for branches and "GT" for tags.
+func version(revision provider.Revision) string {
+	switch revision.Kind {
+	case provider.RevisionBranch:
+		return "GB" + revision.Name
+	case provider.RevisionTag:
+		return "GT" + revision.Name
+	default:
+		return "GC" + revision.Name
+	}
+}
+
+// Selection ends at the start of the line after the range.
+func (a *Azure) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return a.webURL
+	}
+
+	query := url.Values{}
+	query.Set("path", "/"+path)
+	query.Set("version", version(revision))
+	if lines.Start > 0 {
+		end := max(lines.End, lines.Start)
+		query.Set("line", fmt.Sprint(lines.Start))
+		query.Set("lineEnd", fmt.Sprint(end+1))
+		query.Set("lineStartColumn", "1")
+		query.Set("lineEndColumn", "1")
+		query.Set("lineStyle", "plain")
+		query.Set("_a", "contents")
+	}
+
+	return a.webURL + "/" + repo.path() + "?" + query.Encode()
+}
+
+func (a *Azure) CommitURL(projectPath string, sha string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return a.webURL
+	}
+
+	return fmt.Sprintf("%s/%s/commit/%s", a.webURL, repo.path(), sha)
+}
+
+// There is no tag page, so files at the tag are shown instead.
+func (a *Azure) TagURL(projectPath string, tag string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return a.webURL
+	}
+
+	return a.webURL + "/" + repo.path() + "?version=" + url.QueryEscape(version(provider.Revision{Name: tag, Kind: provider.RevisionTag}))
+}
+
+func (a *Azure) CompareURL(projectPath string, base provider.Revision, head provider.Revision) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return a.webURL
+	}
+
+	return fmt.Sprintf("%s/%s/branchCompare?baseVersion=%s&targetVersion=%s", a.webURL, repo.path(), url.QueryEscape(version(base)), url.QueryEscape(version(head)))
+}
+
+func (a *Azure) HomeURL(projectPath string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return a.webURL
+	}
+
+	return a.webURL + "/" + repo.path()
+}
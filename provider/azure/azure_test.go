@@ -0,0 +1,90 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/wowu/pro/provider"
+)
+
+func TestParseProjectPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectPath string
+		want        Repository
+	}{
+		{
+			name:        "https",
+			projectPath: "org/project/_git/repo",
+			want:        Repository{Organization: "org", Project: "project", Name: "repo"},
+		},
+		{
+			name:        "ssh",
+			projectPath: "v3/org/project/repo",
+			want:        Repository{Organization: "org", Project: "project", Name: "repo"},
+		},
+		{
+			name:        "ssh with escaped spaces",
+			projectPath: "v3/org/My%20Project/My%20Repo",
+			want:        Repository{Organization: "org", Project: "My Project", Name: "My Repo"},
+		},
+		{
+			name:        "https with spaces",
+			projectPath: "org/My Project/_git/My Repo",
+			want:        Repository{Organization: "org", Project: "My Project", Name: "My Repo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProjectPath(tt.projectPath)
+			if err != nil {
+				t.Fatalf("ParseProjectPath(%q) returned unexpected error: %v", tt.projectPath, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseProjectPath(%q) = %+v, want %+v", tt.projectPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProjectPathErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectPath string
+	}{
+		{name: "owner and repo only", projectPath: "owner/repo"},
+		{name: "missing _git segment", projectPath: "org/project/repo"},
+		{name: "ssh without repo", projectPath: "v3/org/project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProjectPath(tt.projectPath); err == nil {
+				t.Errorf("ParseProjectPath(%q) = nil error, want error", tt.projectPath)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	a := New(provider.Instance{Host: "ssh.dev.azure.com"})
+
+	if got, want := a.HomeURL("v3/org/My%20Project/repo"), "https://dev.azure.com/org/My%20Project/_git/repo"; got != want {
+		t.Errorf("HomeURL() = %q, want %q", got, want)
+	}
+
+	if got, want := a.CreateURL("org/project/_git/repo", "feature/x"), "https://dev.azure.com/org/project/_git/repo/pullrequestcreate?sourceRef=feature%2Fx"; got != want {
+		t.Errorf("CreateURL() = %q, want %q", got, want)
+	}
+
+	revision := provider.Revision{Name: "abc123", Kind: provider.RevisionCommit}
+	if got, want := a.(provider.FileBrowser).FileURL("org/project/_git/repo", revision, "src/main.go", provider.Lines{Start: 10, End: 20}), "https://dev.azure.com/org/project/_git/repo?_a=contents&line=10&lineEnd=21&lineEndColumn=1&lineStartColumn=1&lineStyle=plain&path=%2Fsrc%2Fmain.go&version=GCabc123"; got != want {
+		t.Errorf("FileURL() = %q, want %q", got, want)
+	}
+
+	base := provider.Revision{Name: "main", Kind: provider.RevisionBranch}
+	head := provider.Revision{Name: "v1.0", Kind: provider.RevisionTag}
+	if got, want := a.(provider.Comparer).CompareURL("org/project/_git/repo", base, head), "https://dev.azure.com/org/project/_git/repo/branchCompare?baseVersion=GBmain&targetVersion=GTv1.0"; got != want {
+		t.Errorf("CompareURL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,303 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+)
+
+func init() {
+	provider.Register(provider.Registration{
+		Info:  info,
+		Hosts: []string{"bitbucket.org"},
+		New:   New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "bitbucket",
+	Title:       "Bitbucket",
+	RequestName: "pull request",
+	RefPrefix:   "#",
+	TokenScopes: "Pull requests: Read",
+	TokenHint:   "Paste app password as \"username:app_password\" or paste access token as is.",
+}
+
+type Bitbucket struct {
+	host   string
+	apiURL string
+	webURL string
+	token  string
+}
+
+// New returns Bitbucket Cloud provider for given instance.
+func New(instance provider.Instance) provider.Provider {
+	webURL := strings.TrimSuffix(instance.WebURL, "/")
+	if webURL == "" {
+		webURL = "https://" + instance.Host
+	}
+
+	apiURL := strings.TrimSuffix(instance.APIURL, "/")
+	if apiURL == "" {
+		apiURL = "https://api.bitbucket.org/2.0"
+	}
+
+	return &Bitbucket{host: instance.Host, apiURL: apiURL, webURL: webURL, token: instance.Token}
+}
+
+type ApiResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Token is either "username:app_password" used with basic auth, or an access
+// token used as bearer token.
+func apiGet(url string, token string) (ApiResponse, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	if username, password, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(username, password)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	return ApiResponse{resp.StatusCode, body}, nil
+}
+
+func (b *Bitbucket) get(url string) (ApiResponse, error) {
+	if b.token == "" {
+		return ApiResponse{}, provider.ErrNoToken
+	}
+
+	return apiGet(url, b.token)
+}
+
+func unknownResponseError(resp ApiResponse) error {
+	return errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+}
+
+func (b *Bitbucket) Info() provider.Info {
+	return info
+}
+
+func (b *Bitbucket) Host() string {
+	return b.host
+}
+
+func (b *Bitbucket) TokenURL() string {
+	return b.webURL + "/account/settings/app-passwords/new"
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-users/#api-user-get
+func (b *Bitbucket) ValidateToken(token string) error {
+	resp, err := apiGet(b.apiURL+"/user", token)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ErrUnauthorized
+	case http.StatusOK, http.StatusForbidden:
+		// Repository and workspace access tokens are not tied to a user,
+		// so they are refused access to user info but are still valid.
+		return nil
+	default:
+		return unknownResponseError(resp)
+	}
+}
+
+type PullRequestResponse struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Source struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"source"`
+	Links struct {
+		Html struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+func (pr PullRequestResponse) changeRequest() provider.ChangeRequest {
+	state := provider.StateOpen
+	switch pr.State {
+	case "MERGED":
+		state = provider.StateMerged
+	case "DECLINED", "SUPERSEDED":
+		state = provider.StateClosed
+	}
+
+	return provider.ChangeRequest{
+		Number: pr.ID,
+		Title:  pr.Title,
+		Branch: pr.Source.Branch.Name,
+		URL:    pr.Links.Html.Href,
+		State:  state,
+	}
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pullrequests/#api-repositories-workspace-repo-slug-pullrequests-get
+func (b *Bitbucket) listPullRequests(projectPath string, query string) ([]PullRequestResponse, error) {
+	url := b.apiURL + "/repositories/" + projectPath + "/pullrequests?state=OPEN&pagelen=50"
+	if query != "" {
+		url += "&q=" + query
+	}
+
+	resp, err := b.get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var page struct {
+			Values []PullRequestResponse `json:"values"`
+		}
+		err = json.Unmarshal(resp.Body, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		return page.Values, nil
+	default:
+		return nil, unknownResponseError(resp)
+	}
+}
+
+func (b *Bitbucket) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	query := url.QueryEscape(fmt.Sprintf("source.branch.name=%q", branch))
+
+	pullRequests, err := b.listPullRequests(projectPath, query)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	if len(pullRequests) == 0 {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	return pullRequests[0].changeRequest(), nil
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pullrequests/#api-repositories-workspace-repo-slug-pullrequests-pull-request-id-get
+func (b *Bitbucket) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	resp, err := b.get(b.apiURL + "/repositories/" + projectPath + "/pullrequests/" + fmt.Sprint(number))
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ChangeRequest{}, provider.ErrUnauthorized
+	// Same status is returned for missing repositories
+	case http.StatusNotFound:
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	case http.StatusOK:
+		var pullRequest PullRequestResponse
+		err = json.Unmarshal(resp.Body, &pullRequest)
+		if err != nil {
+			return provider.ChangeRequest{}, err
+		}
+
+		return pullRequest.changeRequest(), nil
+	default:
+		return provider.ChangeRequest{}, unknownResponseError(resp)
+	}
+}
+
+func (b *Bitbucket) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	pullRequests, err := b.listPullRequests(projectPath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var changeRequests []provider.ChangeRequest
+	for _, pr := range pullRequests {
+		changeRequests = append(changeRequests, pr.changeRequest())
+	}
+
+	return changeRequests, nil
+}
+
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-refs/#api-repositories-workspace-repo-slug-refs-branches-name-get
+func (b *Bitbucket) BranchExists(projectPath string, branch string) (bool, error) {
+	url := b.apiURL + "/repositories/" + projectPath + "/refs/branches/" + provider.EscapePath(branch)
+
+	resp, err := b.get(url)
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return false, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusOK:
+		return true, nil
+	default:
+		return false, unknownResponseError(resp)
+	}
+}
+
+func (b *Bitbucket) CreateURL(projectPath string, branch string) string {
+	return fmt.Sprintf("%s/%s/pull-requests/new?source=%s", b.webURL, projectPath, url.QueryEscape(branch))
+}
+
+func (b *Bitbucket) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	url := fmt.Sprintf("%s/%s/src/%s/%s", b.webURL, projectPath, provider.EscapePath(revision.Name), provider.EscapePath(path))
+	if lines.Start > 0 {
+		url += fmt.Sprintf("#lines-%d", lines.Start)
+	}
+	if lines.End > lines.Start {
+		url += fmt.Sprintf(":%d", lines.End)
+	}
+
+	return url
+}
+
+func (b *Bitbucket) CommitURL(projectPath string, sha string) string {
+	return fmt.Sprintf("%s/%s/commits/%s", b.webURL, projectPath, sha)
+}
+
+func (b *Bitbucket) TagURL(projectPath string, tag string) string {
+	return fmt.Sprintf("%s/%s/commits/tag/%s", b.webURL, projectPath, provider.EscapePath(tag))
+}
+
+// Compare page takes source and destination separated by a carriage return.
+func (b *Bitbucket) CompareURL(projectPath string, base provider.Revision, head provider.Revision) string {
+	return fmt.Sprintf("%s/%s/branches/compare/%s%%0D%s#diff", b.webURL, projectPath, provider.EscapePath(head.Name), provider.EscapePath(base.Name))
+}
+
+func (b *Bitbucket) HomeURL(projectPath string) string {
+	return b.webURL + "/" + projectPath
+}
@@ -0,0 +1,426 @@
+package bitbucketserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+)
+
+// Bitbucket Server and Data Center are always self-hosted, so instances have
+// to be declared in config.
+func init() {
+	provider.Register(provider.Registration{
+		Info: info,
+		New:  New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "bitbucket-server",
+	Title:       "Bitbucket Server",
+	RequestName: "pull request",
+	RefPrefix:   "#",
+	TokenScopes: "Repository read",
+	TokenHint:   "Works with Bitbucket Server and Bitbucket Data Center.",
+}
+
+var ErrInvalidProjectPath = errors.New("unable to find project and repository in remote URL")
+
+type BitbucketServer struct {
+	host   string
+	apiURL string
+	webURL string
+	token  string
+}
+
+// New returns Bitbucket Server provider for given instance. Base URLs default
+// to https://<host> and https://<host>/rest/api/1.0.
+func New(instance provider.Instance) provider.Provider {
+	webURL := strings.TrimSuffix(instance.WebURL, "/")
+	if webURL == "" {
+		webURL = "https://" + instance.Host
+	}
+
+	apiURL := strings.TrimSuffix(instance.APIURL, "/")
+	if apiURL == "" {
+		apiURL = webURL + "/rest/api/1.0"
+	}
+
+	return &BitbucketServer{host: instance.Host, apiURL: apiURL, webURL: webURL, token: instance.Token}
+}
+
+// Repository identifies a repository in Bitbucket Server.
+type Repository struct {
+	// ProjectKey is the key of the project, e.g. "PROJ". Personal
+	// repositories have user slug prefixed with "~" as project key.
+	ProjectKey string
+	Slug       string
+}
+
+// ParseProjectPath decodes project key and repository slug from the path of a
+// remote URL. SSH remotes use "PROJ/repo", HTTP remotes use "scm/PROJ/repo",
+// possibly preceded by the context path of the instance.
+func ParseProjectPath(projectPath string) (Repository, error) {
+	parts := strings.Split(strings.Trim(projectPath, "/"), "/")
+
+	for i, part := range parts {
+		if part != "scm" {
+			continue
+		}
+
+		if len(parts) != i+3 {
+			return Repository{}, ErrInvalidProjectPath
+		}
+		return Repository{ProjectKey: parts[i+1], Slug: parts[i+2]}, nil
+	}
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Repository{}, ErrInvalidProjectPath
+	}
+
+	return Repository{ProjectKey: parts[0], Slug: parts[1]}, nil
+}
+
+// Returns API path of the repository relative to the API base URL.
+func (r Repository) apiPath() string {
+	return "/projects/" + url.PathEscape(r.ProjectKey) + "/repos/" + url.PathEscape(r.Slug)
+}
+
+// Returns web path of the repository relative to the web base URL.
+func (r Repository) webPath() string {
+	if userSlug, ok := strings.CutPrefix(r.ProjectKey, "~"); ok {
+		return "/users/" + url.PathEscape(strings.ToLower(userSlug)) + "/repos/" + url.PathEscape(r.Slug)
+	}
+
+	return "/projects/" + url.PathEscape(r.ProjectKey) + "/repos/" + url.PathEscape(r.Slug)
+}
+
+type ApiResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+func apiGet(url string, token string) (ApiResponse, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	return ApiResponse{resp.StatusCode, body}, nil
+}
+
+func (b *BitbucketServer) get(url string) (ApiResponse, error) {
+	if b.token == "" {
+		return ApiResponse{}, provider.ErrNoToken
+	}
+
+	return apiGet(url, b.token)
+}
+
+func unknownResponseError(resp ApiResponse) error {
+	return errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+}
+
+func (b *BitbucketServer) Info() provider.Info {
+	return info
+}
+
+func (b *BitbucketServer) Host() string {
+	return b.host
+}
+
+func (b *BitbucketServer) TokenURL() string {
+	return b.webURL + "/plugins/servlet/access-tokens/manage"
+}
+
+// Dashboard is only available to authenticated users, unlike most of the API.
+// https://developer.atlassian.com/server/bitbucket/rest/v906/api-group-dashboard/#api-api-latest-dashboard-pull-requests-get
+func (b *BitbucketServer) ValidateToken(token string) error {
+	resp, err := apiGet(b.apiURL+"/dashboard/pull-requests?limit=1", token)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ErrUnauthorized
+	case http.StatusOK:
+		return nil
+	default:
+		return unknownResponseError(resp)
+	}
+}
+
+type PullRequestResponse struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	FromRef struct {
+		DisplayID string `json:"displayId"`
+	} `json:"fromRef"`
+	Links struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+func (b *BitbucketServer) changeRequest(repo Repository, pr PullRequestResponse) provider.ChangeRequest {
+	url := fmt.Sprintf("%s%s/pull-requests/%d", b.webURL, repo.webPath(), pr.ID)
+	if len(pr.Links.Self) > 0 {
+		url = pr.Links.Self[0].Href
+	}
+
+	state := provider.StateOpen
+	switch pr.State {
+	case "MERGED":
+		state = provider.StateMerged
+	case "DECLINED":
+		state = provider.StateClosed
+	}
+
+	return provider.ChangeRequest{
+		Number: pr.ID,
+		Title:  pr.Title,
+		Branch: pr.FromRef.DisplayID,
+		URL:    url,
+		State:  state,
+	}
+}
+
+// https://developer.atlassian.com/server/bitbucket/rest/v906/api-group-pull-requests/#api-api-latest-projects-projectkey-repos-repositoryslug-pull-requests-get
+func (b *BitbucketServer) listPullRequests(repo Repository, branch string) ([]PullRequestResponse, error) {
+	requestURL := b.apiURL + repo.apiPath() + "/pull-requests?state=OPEN&limit=50"
+	if branch != "" {
+		requestURL += "&direction=OUTGOING&at=" + url.QueryEscape("refs/heads/"+branch)
+	}
+
+	resp, err := b.get(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var page struct {
+			Values []PullRequestResponse `json:"values"`
+		}
+		err = json.Unmarshal(resp.Body, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		return page.Values, nil
+	default:
+		return nil, unknownResponseError(resp)
+	}
+}
+
+func (b *BitbucketServer) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	pullRequests, err := b.listPullRequests(repo, branch)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	if len(pullRequests) == 0 {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	return b.changeRequest(repo, pullRequests[0]), nil
+}
+
+// https://developer.atlassian.com/server/bitbucket/rest/v906/api-group-pull-requests/#api-api-latest-projects-projectkey-repos-repositoryslug-pull-requests-pullrequestid-get
+func (b *BitbucketServer) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	resp, err := b.get(b.apiURL + repo.apiPath() + "/pull-requests/" + fmt.Sprint(number))
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ChangeRequest{}, provider.ErrUnauthorized
+	// Same status is returned for missing repositories
+	case http.StatusNotFound:
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	case http.StatusOK:
+		var pullRequest PullRequestResponse
+		err = json.Unmarshal(resp.Body, &pullRequest)
+		if err != nil {
+			return provider.ChangeRequest{}, err
+		}
+
+		return b.changeRequest(repo, pullRequest), nil
+	default:
+		return provider.ChangeRequest{}, unknownResponseError(resp)
+	}
+}
+
+func (b *BitbucketServer) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pullRequests, err := b.listPullRequests(repo, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var changeRequests []provider.ChangeRequest
+	for _, pr := range pullRequests {
+		changeRequests = append(changeRequests, b.changeRequest(repo, pr))
+	}
+
+	return changeRequests, nil
+}
+
+// https://developer.atlassian.com/server/bitbucket/rest/v906/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-branches-get
+func (b *BitbucketServer) BranchExists(projectPath string, branch string) (bool, error) {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return false, err
+	}
+
+	url := b.apiURL + repo.apiPath() + "/branches?limit=100&filterText=" + url.QueryEscape(branch)
+	resp, err := b.get(url)
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return false, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return false, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var page struct {
+			Values []struct {
+				DisplayID string `json:"displayId"`
+			} `json:"values"`
+		}
+		err = json.Unmarshal(resp.Body, &page)
+		if err != nil {
+			return false, err
+		}
+
+		// Filter matches any part of the name, so look for exact match
+		for _, b := range page.Values {
+			if b.DisplayID == branch {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	default:
+		return false, unknownResponseError(resp)
+	}
+}
+
+func (b *BitbucketServer) CreateURL(projectPath string, branch string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return b.webURL
+	}
+
+	return fmt.Sprintf("%s%s/pull-requests?create&sourceBranch=%s", b.webURL, repo.webPath(), url.QueryEscape("refs/heads/"+branch))
+}
+
+// Returns revision as accepted by web pages, with full ref name for branches
+// and tags.
+func refName(revision provider.Revision) string {
+	switch revision.Kind {
+	case provider.RevisionBranch:
+		return "refs/heads/" + revision.Name
+	case provider.RevisionTag:
+		return "refs/tags/" + revision.Name
+	default:
+		return revision.Name
+	}
+}
+
+func (b *BitbucketServer) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return b.webURL
+	}
+
+	fileURL := fmt.Sprintf("%s%s/browse/%s?at=%s", b.webURL, repo.webPath(), provider.EscapePath(path), url.QueryEscape(refName(revision)))
+	if lines.Start > 0 {
+		fileURL += fmt.Sprintf("#%d", lines.Start)
+	}
+	if lines.End > lines.Start {
+		fileURL += fmt.Sprintf("-%d", lines.End)
+	}
+
+	return fileURL
+}
+
+func (b *BitbucketServer) CommitURL(projectPath string, sha string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return b.webURL
+	}
+
+	return fmt.Sprintf("%s%s/commits/%s", b.webURL, repo.webPath(), sha)
+}
+
+// There is no tag page, so files at the tag are shown instead.
+func (b *BitbucketServer) TagURL(projectPath string, tag string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return b.webURL
+	}
+
+	return fmt.Sprintf("%s%s/browse?at=%s", b.webURL, repo.webPath(), url.QueryEscape("refs/tags/"+tag))
+}
+
+func (b *BitbucketServer) CompareURL(projectPath string, base provider.Revision, head provider.Revision) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return b.webURL
+	}
+
+	return fmt.Sprintf("%s%s/compare/diff?sourceBranch=%s&targetBranch=%s", b.webURL, repo.webPath(), url.QueryEscape(refName(head)), url.QueryEscape(refName(base)))
+}
+
+func (b *BitbucketServer) HomeURL(projectPath string) string {
+	repo, err := ParseProjectPath(projectPath)
+	if err != nil {
+		return b.webURL
+	}
+
+	return b.webURL + repo.webPath() + "/browse"
+}
@@ -0,0 +1,90 @@
+package bitbucketserver
+
+import (
+	"testing"
+
+	"github.com/wowu/pro/provider"
+)
+
+func TestParseProjectPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectPath string
+		want        Repository
+	}{
+		{
+			name:        "ssh",
+			projectPath: "PROJ/repo",
+			want:        Repository{ProjectKey: "PROJ", Slug: "repo"},
+		},
+		{
+			name:        "http",
+			projectPath: "scm/PROJ/repo",
+			want:        Repository{ProjectKey: "PROJ", Slug: "repo"},
+		},
+		{
+			name:        "http with context path",
+			projectPath: "bitbucket/scm/PROJ/repo",
+			want:        Repository{ProjectKey: "PROJ", Slug: "repo"},
+		},
+		{
+			name:        "personal repository",
+			projectPath: "scm/~jdoe/repo",
+			want:        Repository{ProjectKey: "~jdoe", Slug: "repo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProjectPath(tt.projectPath)
+			if err != nil {
+				t.Fatalf("ParseProjectPath(%q) returned unexpected error: %v", tt.projectPath, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseProjectPath(%q) = %+v, want %+v", tt.projectPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProjectPathErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectPath string
+	}{
+		{name: "repo only", projectPath: "repo"},
+		{name: "too many segments", projectPath: "a/b/c"},
+		{name: "scm without repo", projectPath: "scm/PROJ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProjectPath(tt.projectPath); err == nil {
+				t.Errorf("ParseProjectPath(%q) = nil error, want error", tt.projectPath)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	b := New(provider.Instance{Host: "bitbucket.corp"})
+
+	if got, want := b.CreateURL("PROJ/repo", "feature/x"), "https://bitbucket.corp/projects/PROJ/repos/repo/pull-requests?create&sourceBranch=refs%2Fheads%2Ffeature%2Fx"; got != want {
+		t.Errorf("CreateURL() = %q, want %q", got, want)
+	}
+
+	if got, want := b.HomeURL("scm/~JDoe/repo"), "https://bitbucket.corp/users/jdoe/repos/repo/browse"; got != want {
+		t.Errorf("HomeURL() = %q, want %q", got, want)
+	}
+
+	revision := provider.Revision{Name: "feature/x", Kind: provider.RevisionBranch}
+	if got, want := b.(provider.FileBrowser).FileURL("PROJ/repo", revision, "src/main.go", provider.Lines{Start: 10, End: 20}), "https://bitbucket.corp/projects/PROJ/repos/repo/browse/src/main.go?at=refs%2Fheads%2Ffeature%2Fx#10-20"; got != want {
+		t.Errorf("FileURL() = %q, want %q", got, want)
+	}
+
+	base := provider.Revision{Name: "main", Kind: provider.RevisionBranch}
+	head := provider.Revision{Name: "abc123", Kind: provider.RevisionCommit}
+	if got, want := b.(provider.Comparer).CompareURL("PROJ/repo", base, head), "https://bitbucket.corp/projects/PROJ/repos/repo/compare/diff?sourceBranch=abc123&targetBranch=refs%2Fheads%2Fmain"; got != want {
+		t.Errorf("CompareURL() = %q, want %q", got, want)
+	}
+}
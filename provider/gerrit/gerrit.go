@@ -0,0 +1,322 @@
+package gerrit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+)
+
+// Gerrit instances are self-hosted, so they have to be declared in config.
+func init() {
+	provider.Register(provider.Registration{
+		Info: info,
+		New:  New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "gerrit",
+	Title:       "Gerrit",
+	RequestName: "change",
+	TokenHint:   "Paste HTTP credentials as \"username:password\".",
+}
+
+// Gerrit prefixes JSON responses with this line to prevent XSSI.
+var magicPrefix = []byte(")]}'")
+
+type Gerrit struct {
+	host   string
+	webURL string
+	token  string
+}
+
+// New returns Gerrit provider for given instance. Base URL defaults to
+// https://<host>, API is served from the same URL.
+func New(instance provider.Instance) provider.Provider {
+	webURL := strings.TrimSuffix(instance.WebURL, "/")
+	if webURL == "" {
+		webURL = "https://" + instance.Host
+	}
+
+	return &Gerrit{host: instance.Host, webURL: webURL, token: instance.Token}
+}
+
+type ApiResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Token is "username:password" with HTTP credentials of the user. Without
+// token, requests are anonymous, which is enough for public instances.
+func apiGet(url string, token string) (ApiResponse, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	if username, password, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(username, password)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	return ApiResponse{resp.StatusCode, bytes.TrimPrefix(body, magicPrefix)}, nil
+}
+
+// Authenticated endpoints are served under "/a/" prefix.
+// https://gerrit-review.googlesource.com/Documentation/rest-api.html#authentication
+func (g *Gerrit) get(path string) (ApiResponse, error) {
+	if g.token == "" {
+		return apiGet(g.webURL+path, "")
+	}
+
+	return apiGet(g.webURL+"/a"+path, g.token)
+}
+
+func unknownResponseError(resp ApiResponse) error {
+	return errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+}
+
+// Returns Gerrit project name for the path of a remote URL. HTTP remotes may
+// include the context path of the instance and "a/" prefix of authenticated
+// access, e.g. "r/a/platform/build" is "platform/build".
+func (g *Gerrit) projectName(projectPath string) string {
+	if u, err := url.Parse(g.webURL); err == nil {
+		contextPath := strings.Trim(u.Path, "/")
+		if contextPath != "" {
+			projectPath = strings.TrimPrefix(projectPath, contextPath+"/")
+		}
+	}
+
+	return strings.TrimPrefix(projectPath, "a/")
+}
+
+func (g *Gerrit) Info() provider.Info {
+	return info
+}
+
+func (g *Gerrit) Host() string {
+	return g.host
+}
+
+func (g *Gerrit) TokenURL() string {
+	return g.webURL + "/settings/#HTTPCredentials"
+}
+
+// https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#get-account
+func (g *Gerrit) ValidateToken(token string) error {
+	if !strings.Contains(token, ":") {
+		return provider.ErrUnauthorized
+	}
+
+	resp, err := apiGet(g.webURL+"/a/accounts/self", token)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ErrUnauthorized
+	case http.StatusOK:
+		return nil
+	default:
+		return unknownResponseError(resp)
+	}
+}
+
+type ChangeResponse struct {
+	Number   int    `json:"_number"`
+	ChangeID string `json:"change_id"`
+	Project  string `json:"project"`
+	Branch   string `json:"branch"`
+	Subject  string `json:"subject"`
+	Status   string `json:"status"`
+}
+
+func (g *Gerrit) changeRequest(change ChangeResponse) provider.ChangeRequest {
+	state := provider.StateOpen
+	switch change.Status {
+	case "MERGED":
+		state = provider.StateMerged
+	case "ABANDONED":
+		state = provider.StateClosed
+	}
+
+	return provider.ChangeRequest{
+		Number: change.Number,
+		Title:  change.Subject,
+		Branch: change.Branch,
+		URL:    fmt.Sprintf("%s/c/%s/+/%d", g.webURL, change.Project, change.Number),
+		State:  state,
+	}
+}
+
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#list-changes
+func (g *Gerrit) queryChanges(query string) ([]ChangeResponse, error) {
+	resp, err := g.get("/changes/?n=50&q=" + url.QueryEscape(query))
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, provider.ErrUnauthorized
+	case http.StatusOK:
+		var changes []ChangeResponse
+		err = json.Unmarshal(resp.Body, &changes)
+		if err != nil {
+			return nil, err
+		}
+
+		return changes, nil
+	default:
+		return nil, unknownResponseError(resp)
+	}
+}
+
+// Changes are matched by Change-Id. Same Change-Id may be used by changes
+// cherry-picked to other branches, open change is preferred over merged or
+// abandoned ones.
+func (g *Gerrit) FindChangeRequestForCommit(projectPath string, commit provider.Commit) (provider.ChangeRequest, error) {
+	if commit.ChangeID == "" {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	changes, err := g.queryChanges("change:" + commit.ChangeID + " project:" + g.projectName(projectPath))
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	if len(changes) == 0 {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	for _, change := range changes {
+		if change.Status == "NEW" {
+			return g.changeRequest(change), nil
+		}
+	}
+
+	return g.changeRequest(changes[0]), nil
+}
+
+func (g *Gerrit) SubmitInstructions(projectPath string, remote string, branch string) (string, string) {
+	instructions := fmt.Sprintf("Push the commit for review with:\n\n  git push %s HEAD:refs/for/%s\n\n"+
+		"Make sure the commit message has a Change-Id trailer.", remote, branch)
+
+	return instructions, g.webURL + "/Documentation/user-upload.html"
+}
+
+// Gerrit changes are not tied to source branches, so open change with topic
+// named after the branch is searched instead, as set by pushing to
+// refs/for/<target>%topic=<branch>.
+func (g *Gerrit) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	changes, err := g.queryChanges("status:open project:" + g.projectName(projectPath) + " topic:" + branch)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	if len(changes) == 0 {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	return g.changeRequest(changes[0]), nil
+}
+
+// Changes are queried by number within the project, so numbers of changes in
+// other projects of the instance are not found.
+func (g *Gerrit) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	changes, err := g.queryChanges(fmt.Sprintf("change:%d project:%s", number, g.projectName(projectPath)))
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	if len(changes) == 0 {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	return g.changeRequest(changes[0]), nil
+}
+
+func (g *Gerrit) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	changes, err := g.queryChanges("status:open project:" + g.projectName(projectPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var changeRequests []provider.ChangeRequest
+	for _, change := range changes {
+		changeRequests = append(changeRequests, g.changeRequest(change))
+	}
+
+	return changeRequests, nil
+}
+
+// https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-branch
+func (g *Gerrit) BranchExists(projectPath string, branch string) (bool, error) {
+	resp, err := g.get("/projects/" + url.PathEscape(g.projectName(projectPath)) + "/branches/" + url.PathEscape(branch))
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return false, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusOK:
+		return true, nil
+	default:
+		return false, unknownResponseError(resp)
+	}
+}
+
+// Gerrit has no page creating changes, they are created by pushing to
+// refs/for/<branch>. Returns list of open changes targeting the branch.
+func (g *Gerrit) CreateURL(projectPath string, branch string) string {
+	return g.webURL + "/q/" + url.PathEscape("status:open project:"+g.projectName(projectPath)+" branch:"+branch)
+}
+
+// Files are shown by Gitiles plugin, which highlights only a single line.
+// https://gerrit.googlesource.com/plugins/gitiles/+/HEAD/Documentation/design.md
+func (g *Gerrit) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	url := fmt.Sprintf("%s/plugins/gitiles/%s/+/%s/%s", g.webURL, g.projectName(projectPath), provider.EscapePath(revision.Name), provider.EscapePath(path))
+	if lines.Start > 0 {
+		url += fmt.Sprintf("#%d", lines.Start)
+	}
+
+	return url
+}
+
+func (g *Gerrit) CommitURL(projectPath string, sha string) string {
+	return fmt.Sprintf("%s/plugins/gitiles/%s/+/%s", g.webURL, g.projectName(projectPath), sha)
+}
+
+func (g *Gerrit) TagURL(projectPath string, tag string) string {
+	return fmt.Sprintf("%s/plugins/gitiles/%s/+/refs/tags/%s", g.webURL, g.projectName(projectPath), provider.EscapePath(tag))
+}
+
+// Gitiles shows log of commits in head missing in base instead of a diff.
+func (g *Gerrit) CompareURL(projectPath string, base provider.Revision, head provider.Revision) string {
+	return fmt.Sprintf("%s/plugins/gitiles/%s/+log/%s..%s", g.webURL, g.projectName(projectPath), provider.EscapePath(base.Name), provider.EscapePath(head.Name))
+}
+
+func (g *Gerrit) HomeURL(projectPath string) string {
+	return g.webURL + "/admin/repos/" + url.PathEscape(g.projectName(projectPath))
+}
@@ -0,0 +1,327 @@
+package gitea
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+)
+
+// Gitea API is shared by Forgejo and Codeberg, other instances are declared in config.
+func init() {
+	provider.Register(provider.Registration{
+		Info:  info,
+		Hosts: []string{"codeberg.org"},
+		New:   New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "gitea",
+	Title:       "Gitea",
+	RequestName: "pull request",
+	RefPrefix:   "#",
+	TokenScopes: "read:repository",
+	TokenHint:   "Works with Gitea, Forgejo and Codeberg.",
+}
+
+// Number of items per page, the default maximum of Gitea instances.
+const pageSize = 50
+
+type Gitea struct {
+	host   string
+	apiURL string
+	webURL string
+	token  string
+}
+
+// New returns Gitea provider for given instance. Base URLs default to
+// https://<host> and https://<host>/api/v1.
+func New(instance provider.Instance) provider.Provider {
+	webURL := strings.TrimSuffix(instance.WebURL, "/")
+	if webURL == "" {
+		webURL = "https://" + instance.Host
+	}
+
+	apiURL := strings.TrimSuffix(instance.APIURL, "/")
+	if apiURL == "" {
+		apiURL = webURL + "/api/v1"
+	}
+
+	return &Gitea{host: instance.Host, apiURL: apiURL, webURL: webURL, token: instance.Token}
+}
+
+type ApiResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+func apiGet(url string, token string) (ApiResponse, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	req.Header.Set("Authorization", "token "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+
+	return ApiResponse{resp.StatusCode, body}, nil
+}
+
+func (g *Gitea) get(url string) (ApiResponse, error) {
+	if g.token == "" {
+		return ApiResponse{}, provider.ErrNoToken
+	}
+
+	return apiGet(url, g.token)
+}
+
+func unknownResponseError(resp ApiResponse) error {
+	return errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+}
+
+func (g *Gitea) Info() provider.Info {
+	return info
+}
+
+func (g *Gitea) Host() string {
+	return g.host
+}
+
+func (g *Gitea) TokenURL() string {
+	return g.webURL + "/user/settings/applications"
+}
+
+// https://try.gitea.io/api/swagger#/user/userGetCurrent
+func (g *Gitea) ValidateToken(token string) error {
+	resp, err := apiGet(g.apiURL+"/user", token)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ErrUnauthorized
+	case http.StatusOK:
+		return nil
+	default:
+		return unknownResponseError(resp)
+	}
+}
+
+type PullRequestResponse struct {
+	ID     int    `json:"id"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Head   struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	HtmlURL string `json:"html_url"`
+	Merged  bool   `json:"merged"`
+}
+
+func (pr PullRequestResponse) changeRequest() provider.ChangeRequest {
+	state := provider.StateOpen
+	if pr.Merged {
+		state = provider.StateMerged
+	} else if pr.State == "closed" {
+		state = provider.StateClosed
+	}
+
+	return provider.ChangeRequest{
+		Number: pr.Number,
+		Title:  pr.Title,
+		Branch: pr.Head.Ref,
+		URL:    pr.HtmlURL,
+		State:  state,
+	}
+}
+
+// https://try.gitea.io/api/swagger#/repository/repoListPullRequests
+func (g *Gitea) listPullRequests(projectPath string, page int) ([]PullRequestResponse, error) {
+	url := fmt.Sprintf("%s/repos/%s/pulls?state=open&sort=recentupdate&limit=%d&page=%d", g.apiURL, projectPath, pageSize, page)
+	resp, err := g.get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var pullRequests []PullRequestResponse
+		err = json.Unmarshal(resp.Body, &pullRequests)
+		if err != nil {
+			return nil, err
+		}
+
+		return pullRequests, nil
+	default:
+		return nil, unknownResponseError(resp)
+	}
+}
+
+// API has no filter by head branch, so open pull requests are searched page by page.
+func (g *Gitea) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	for page := 1; ; page++ {
+		pullRequests, err := g.listPullRequests(projectPath, page)
+		if err != nil {
+			return provider.ChangeRequest{}, err
+		}
+
+		for _, pr := range pullRequests {
+			if pr.Head.Ref == branch {
+				return pr.changeRequest(), nil
+			}
+		}
+
+		if len(pullRequests) < pageSize {
+			return provider.ChangeRequest{}, provider.ErrNotFound
+		}
+	}
+}
+
+// https://try.gitea.io/api/swagger#/repository/repoGetPullRequest
+func (g *Gitea) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath + "/pulls/" + fmt.Sprint(number))
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ChangeRequest{}, provider.ErrUnauthorized
+	// Same status is returned for missing repositories
+	case http.StatusNotFound:
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	case http.StatusOK:
+		var pullRequest PullRequestResponse
+		err = json.Unmarshal(resp.Body, &pullRequest)
+		if err != nil {
+			return provider.ChangeRequest{}, err
+		}
+
+		return pullRequest.changeRequest(), nil
+	default:
+		return provider.ChangeRequest{}, unknownResponseError(resp)
+	}
+}
+
+func (g *Gitea) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	pullRequests, err := g.listPullRequests(projectPath, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var changeRequests []provider.ChangeRequest
+	for _, pr := range pullRequests {
+		changeRequests = append(changeRequests, pr.changeRequest())
+	}
+
+	return changeRequests, nil
+}
+
+// https://try.gitea.io/api/swagger#/repository/repoGetBranch
+func (g *Gitea) BranchExists(projectPath string, branch string) (bool, error) {
+	url := g.apiURL + "/repos/" + projectPath + "/branches/" + provider.EscapePath(branch)
+	resp, err := g.get(url)
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return false, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusOK:
+		return true, nil
+	default:
+		return false, unknownResponseError(resp)
+	}
+}
+
+// https://try.gitea.io/api/swagger#/repository/repoGet
+func (g *Gitea) defaultBranch(projectPath string) (string, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return "", provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return "", provider.ErrProjectNotFound
+	case http.StatusOK:
+		var repository struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		err = json.Unmarshal(resp.Body, &repository)
+		if err != nil {
+			return "", err
+		}
+
+		return repository.DefaultBranch, nil
+	default:
+		return "", unknownResponseError(resp)
+	}
+}
+
+// Compare page is opened against the default branch of the repository. When
+// it can't be read, only the branch is given and Gitea compares it with the
+// default branch itself.
+func (g *Gitea) CreateURL(projectPath string, branch string) string {
+	base, err := g.defaultBranch(projectPath)
+	if err != nil || base == "" {
+		return fmt.Sprintf("%s/%s/compare/%s", g.webURL, projectPath, provider.EscapePath(branch))
+	}
+
+	return fmt.Sprintf("%s/%s/compare/%s...%s", g.webURL, projectPath, provider.EscapePath(base), provider.EscapePath(branch))
+}
+
+// Source pages have kind of revision in the path, e.g. "src/branch/main".
+func (g *Gitea) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	url := fmt.Sprintf("%s/%s/src/%s/%s/%s", g.webURL, projectPath, revision.Kind, provider.EscapePath(revision.Name), provider.EscapePath(path))
+	if lines.Start > 0 {
+		url += fmt.Sprintf("#L%d", lines.Start)
+	}
+	if lines.End > lines.Start {
+		url += fmt.Sprintf("-L%d", lines.End)
+	}
+
+	return url
+}
+
+func (g *Gitea) CommitURL(projectPath string, sha string) string {
+	return fmt.Sprintf("%s/%s/commit/%s", g.webURL, projectPath, sha)
+}
+
+func (g *Gitea) TagURL(projectPath string, tag string) string {
+	return fmt.Sprintf("%s/%s/releases/tag/%s", g.webURL, projectPath, provider.EscapePath(tag))
+}
+
+func (g *Gitea) CompareURL(projectPath string, base provider.Revision, head provider.Revision) string {
+	return fmt.Sprintf("%s/%s/compare/%s...%s", g.webURL, projectPath, provider.EscapePath(base.Name), provider.EscapePath(head.Name))
+}
+
+func (g *Gitea) HomeURL(projectPath string) string {
+	return g.webURL + "/" + projectPath
+}
@@ -8,10 +8,53 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/wowu/pro/provider"
 )
 
-var ErrUnauthorized = errors.New("unauthorized")
-var ErrNotFound = errors.New("not found")
+func init() {
+	provider.Register(provider.Registration{
+		Info:  info,
+		Hosts: []string{"github.com"},
+		New:   New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "github",
+	Title:       "GitHub",
+	RequestName: "pull request",
+	RefPrefix:   "#",
+	TokenScopes: "repo",
+	TokenHint:   "It's recommended to set expiration to \"No expiration\"",
+}
+
+type GitHub struct {
+	host   string
+	apiURL string
+	webURL string
+	token  string
+}
+
+// New returns GitHub provider for given instance. GitHub Enterprise Server
+// base URLs default to https://<host> and https://<host>/api/v3.
+func New(instance provider.Instance) provider.Provider {
+	webURL := strings.TrimSuffix(instance.WebURL, "/")
+	if webURL == "" {
+		webURL = "https://" + instance.Host
+	}
+
+	apiURL := strings.TrimSuffix(instance.APIURL, "/")
+	if apiURL == "" {
+		if instance.Host == "github.com" {
+			apiURL = "https://api.github.com"
+		} else {
+			apiURL = webURL + "/api/v3"
+		}
+	}
+
+	return &GitHub{host: instance.Host, apiURL: apiURL, webURL: webURL, token: instance.Token}
+}
 
 type ApiResponse struct {
 	StatusCode int
@@ -40,12 +83,32 @@ func apiGet(url string, token string) (ApiResponse, error) {
 	return ApiResponse{resp.StatusCode, body}, nil
 }
 
+func (g *GitHub) get(url string) (ApiResponse, error) {
+	if g.token == "" {
+		return ApiResponse{}, provider.ErrNoToken
+	}
+
+	return apiGet(url, g.token)
+}
+
+func (g *GitHub) Info() provider.Info {
+	return info
+}
+
+func (g *GitHub) Host() string {
+	return g.host
+}
+
+func (g *GitHub) TokenURL() string {
+	return g.webURL + "/settings/tokens/new?description=pro+cli&scopes=repo"
+}
+
 type UserResponse struct {
 	ID int `json:"id"`
 }
 
-func User(token string) (UserResponse, error) {
-	url := "https://api.github.com/user"
+func (g *GitHub) user(token string) (UserResponse, error) {
+	url := g.apiURL + "/user"
 	resp, err := apiGet(url, token)
 	if err != nil {
 		return UserResponse{}, err
@@ -53,7 +116,7 @@ func User(token string) (UserResponse, error) {
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		return UserResponse{}, ErrUnauthorized
+		return UserResponse{}, provider.ErrUnauthorized
 	case http.StatusOK:
 		var user UserResponse
 		err = json.Unmarshal(resp.Body, &user)
@@ -67,6 +130,11 @@ func User(token string) (UserResponse, error) {
 	}
 }
 
+func (g *GitHub) ValidateToken(token string) error {
+	_, err := g.user(token)
+	return err
+}
+
 type PullRequestResponse struct {
 	ID     int    `json:"id"`
 	Number int    `json:"number"`
@@ -74,50 +142,527 @@ type PullRequestResponse struct {
 	State  string `json:"state"`
 	Head   struct {
 		Ref string `json:"ref"`
+		Sha string `json:"sha"`
 	} `json:"head"`
-	HtmlURL string `json:"html_url"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Draft              bool    `json:"draft"`
+	MergeableState     string  `json:"mergeable_state"`
+	RequestedReviewers []any   `json:"requested_reviewers"`
+	RequestedTeams     []any   `json:"requested_teams"`
+	HtmlURL            string  `json:"html_url"`
+	MergedAt           *string `json:"merged_at"`
 }
 
-func FindPullRequest(projectPath string, token string, branch string) (PullRequestResponse, error) {
-	userOrOrg := strings.Split(projectPath, "/")[0]
-	url := "https://api.github.com/repos/" + projectPath + "/pulls?state=open&head=" + userOrOrg + ":" + url.QueryEscape(branch)
+func (pr PullRequestResponse) changeRequest() provider.ChangeRequest {
+	state := provider.StateOpen
+	if pr.MergedAt != nil {
+		state = provider.StateMerged
+	} else if pr.State == "closed" {
+		state = provider.StateClosed
+	}
 
-	resp, err := apiGet(url, token)
+	return provider.ChangeRequest{
+		Number: pr.Number,
+		Title:  pr.Title,
+		Branch: pr.Head.Ref,
+		URL:    pr.HtmlURL,
+		State:  state,
+	}
+}
+
+func (g *GitHub) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	return g.findPullRequest(projectPath, projectPath, branch)
+}
+
+// Pull requests are matched by head in "owner:branch" form, where owner is the
+// owner of the repository the branch was pushed to.
+// https://docs.github.com/en/rest/pulls/pulls?apiVersion=2022-11-28#list-pull-requests
+func (g *GitHub) findPullRequest(projectPath string, headPath string, branch string) (provider.ChangeRequest, error) {
+	headOwner := strings.Split(headPath, "/")[0]
+	url := g.apiURL + "/repos/" + projectPath + "/pulls?state=open&head=" + headOwner + ":" + url.QueryEscape(branch)
+
+	resp, err := g.get(url)
 	if err != nil {
-		return PullRequestResponse{}, err
+		return provider.ChangeRequest{}, err
 	}
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		return PullRequestResponse{}, ErrUnauthorized
+		return provider.ChangeRequest{}, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return provider.ChangeRequest{}, provider.ErrProjectNotFound
 	case http.StatusOK:
 		var pullRequests []PullRequestResponse
 		err = json.Unmarshal(resp.Body, &pullRequests)
 		if err != nil {
-			return PullRequestResponse{}, err
+			return provider.ChangeRequest{}, err
 		}
 
 		if len(pullRequests) == 0 {
-			return PullRequestResponse{}, ErrNotFound
+			return provider.ChangeRequest{}, provider.ErrNotFound
 		}
 
-		return pullRequests[0], nil
+		return pullRequests[0].changeRequest(), nil
 	default:
-		return PullRequestResponse{}, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+		return provider.ChangeRequest{}, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
 	}
 }
 
-func GetRemoteBranches(projectPath string, token string) ([]string, error) {
-	url := "https://api.github.com/repos/" + projectPath + "/git/refs/heads"
+// https://docs.github.com/en/rest/pulls/pulls?apiVersion=2022-11-28#get-a-pull-request
+func (g *GitHub) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath + "/pulls/" + fmt.Sprint(number))
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
 
-	resp, err := apiGet(url, token)
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ChangeRequest{}, provider.ErrUnauthorized
+	// Same status is returned for missing repositories
+	case http.StatusNotFound:
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	case http.StatusOK:
+		var pullRequest PullRequestResponse
+		err = json.Unmarshal(resp.Body, &pullRequest)
+		if err != nil {
+			return provider.ChangeRequest{}, err
+		}
+
+		return pullRequest.changeRequest(), nil
+	default:
+		return provider.ChangeRequest{}, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+	}
+}
+
+// https://docs.github.com/en/rest/commits/commits?apiVersion=2022-11-28#list-pull-requests-associated-with-a-commit
+func (g *GitHub) ChangeRequestsForCommit(projectPath string, sha string) ([]provider.ChangeRequest, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath + "/commits/" + sha + "/pulls")
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
+	// Commit which was not pushed
+	case http.StatusUnprocessableEntity:
+		return nil, provider.ErrNotFound
+	case http.StatusOK:
+		var pullRequests []PullRequestResponse
+		err = json.Unmarshal(resp.Body, &pullRequests)
+		if err != nil {
+			return nil, err
+		}
+
+		var changeRequests []provider.ChangeRequest
+		for _, pr := range pullRequests {
+			changeRequests = append(changeRequests, pr.changeRequest())
+		}
+
+		return changeRequests, nil
+	default:
+		return nil, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+	}
+}
+
+type WorkflowRunResponse struct {
+	Name       string `json:"name"`
+	WorkflowID int    `json:"workflow_id"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HtmlURL    string `json:"html_url"`
+}
+
+// Returns status of a workflow run or check suite, given its status and
+// conclusion.
+func pipelineStatus(status string, conclusion string) string {
+	if status == "in_progress" {
+		return provider.PipelineRunning
+	}
+	if status != "completed" {
+		return provider.PipelinePending
+	}
+
+	switch conclusion {
+	case "success", "neutral":
+		return provider.PipelineSuccess
+	case "cancelled":
+		return provider.PipelineCanceled
+	case "skipped":
+		return provider.PipelineSkipped
+	default:
+		return provider.PipelineFailed
+	}
+}
+
+// Returns overall status of several workflow runs or check suites: failed if
+// any of them failed, as the result can't change anymore, otherwise running if
+// any of them is still running.
+func combinedStatus(statuses []string) string {
+	priority := []string{
+		provider.PipelineFailed,
+		provider.PipelineRunning,
+		provider.PipelinePending,
+		provider.PipelineCanceled,
+		provider.PipelineSuccess,
+	}
+
+	for _, status := range priority {
+		for _, s := range statuses {
+			if s == status {
+				return status
+			}
+		}
+	}
+
+	return provider.PipelineSkipped
+}
+
+// Returns the latest run of each workflow, given runs listed newest first, as
+// workflows may be re-run for the same commit.
+func latestRuns(runs []WorkflowRunResponse) []WorkflowRunResponse {
+	seen := map[int]bool{}
+	var latest []WorkflowRunResponse
+	for _, run := range runs {
+		if seen[run.WorkflowID] {
+			continue
+		}
+		seen[run.WorkflowID] = true
+		latest = append(latest, run)
+	}
+
+	return latest
+}
+
+// Latest run of each workflow triggered for the commit is taken. A single
+// workflow run is opened directly, several are shown on the checks page of
+// the commit, which also lists checks of other CI apps.
+// https://docs.github.com/en/rest/actions/workflow-runs?apiVersion=2022-11-28#list-workflow-runs-for-a-repository
+func (g *GitHub) FindPipeline(projectPath string, sha string) (provider.Pipeline, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath + "/actions/runs?per_page=50&head_sha=" + sha)
+	if err != nil {
+		return provider.Pipeline{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.Pipeline{}, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return provider.Pipeline{}, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var page struct {
+			WorkflowRuns []WorkflowRunResponse `json:"workflow_runs"`
+		}
+		err = json.Unmarshal(resp.Body, &page)
+		if err != nil {
+			return provider.Pipeline{}, err
+		}
+
+		runs := latestRuns(page.WorkflowRuns)
+		var statuses []string
+		for _, run := range runs {
+			statuses = append(statuses, pipelineStatus(run.Status, run.Conclusion))
+		}
+
+		switch len(runs) {
+		case 0:
+			return g.findCheckSuites(projectPath, sha)
+		case 1:
+			return provider.Pipeline{Name: runs[0].Name, Status: statuses[0], URL: runs[0].HtmlURL}, nil
+		default:
+			return provider.Pipeline{
+				Name:   fmt.Sprintf("%d workflows", len(runs)),
+				Status: combinedStatus(statuses),
+				URL:    g.commitChecksURL(projectPath, sha),
+			}, nil
+		}
+	default:
+		return provider.Pipeline{}, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+	}
+}
+
+// Checks of CI apps other than GitHub Actions are grouped in check suites.
+// Suites without check runs are created for every installed app, so they are
+// skipped.
+// https://docs.github.com/en/rest/checks/suites?apiVersion=2022-11-28#list-check-suites-for-a-git-reference
+func (g *GitHub) findCheckSuites(projectPath string, sha string) (provider.Pipeline, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath + "/commits/" + sha + "/check-suites")
+	if err != nil {
+		return provider.Pipeline{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.Pipeline{}, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return provider.Pipeline{}, provider.ErrProjectNotFound
+	// Commit which was not pushed
+	case http.StatusUnprocessableEntity:
+		return provider.Pipeline{}, provider.ErrNotFound
+	case http.StatusOK:
+		var page struct {
+			CheckSuites []struct {
+				Status               string `json:"status"`
+				Conclusion           string `json:"conclusion"`
+				LatestCheckRunsCount int    `json:"latest_check_runs_count"`
+				App                  struct {
+					Name string `json:"name"`
+				} `json:"app"`
+			} `json:"check_suites"`
+		}
+		err = json.Unmarshal(resp.Body, &page)
+		if err != nil {
+			return provider.Pipeline{}, err
+		}
+
+		var names []string
+		var statuses []string
+		for _, suite := range page.CheckSuites {
+			if suite.LatestCheckRunsCount == 0 {
+				continue
+			}
+			names = append(names, suite.App.Name)
+			statuses = append(statuses, pipelineStatus(suite.Status, suite.Conclusion))
+		}
+
+		if len(names) == 0 {
+			return provider.Pipeline{}, provider.ErrNotFound
+		}
+
+		return provider.Pipeline{
+			Name:   strings.Join(names, ", "),
+			Status: combinedStatus(statuses),
+			URL:    g.commitChecksURL(projectPath, sha),
+		}, nil
+	default:
+		return provider.Pipeline{}, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+	}
+}
+
+type ReviewResponse struct {
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	State string `json:"state"`
+}
+
+// Returns review state and number of approvals, given reviews listed oldest
+// first. Only the latest approving or rejecting review of each user counts,
+// comments don't change the state.
+func reviewState(reviews []ReviewResponse, reviewRequested bool) (string, int) {
+	latest := map[string]string{}
+	for _, review := range reviews {
+		switch review.State {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[review.User.Login] = review.State
+		}
+	}
+
+	approvals := 0
+	changesRequested := false
+	for _, state := range latest {
+		switch state {
+		case "APPROVED":
+			approvals++
+		case "CHANGES_REQUESTED":
+			changesRequested = true
+		}
+	}
+
+	switch {
+	case changesRequested:
+		return provider.ReviewChangesRequested, approvals
+	case reviewRequested:
+		return provider.ReviewRequired, approvals
+	case approvals > 0:
+		return provider.ReviewApproved, approvals
+	default:
+		return provider.ReviewNone, approvals
+	}
+}
+
+// Returns merge state of a pull request given its mergeable_state, which is
+// computed in background and is "unknown" until it's ready.
+func mergeableState(state string) string {
+	switch state {
+	case "clean", "unstable", "has_hooks":
+		return provider.MergeableYes
+	case "dirty":
+		return provider.MergeableConflicts
+	case "blocked", "behind", "draft":
+		return provider.MergeableBlocked
+	default:
+		return provider.MergeableUnknown
+	}
+}
+
+// https://docs.github.com/en/rest/pulls/reviews?apiVersion=2022-11-28#list-reviews-for-a-pull-request
+func (g *GitHub) reviews(projectPath string, number int) ([]ReviewResponse, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath + "/pulls/" + fmt.Sprint(number) + "/reviews?per_page=100")
 	if err != nil {
 		return nil, err
 	}
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrNotFound
+	case http.StatusOK:
+		var reviews []ReviewResponse
+		err = json.Unmarshal(resp.Body, &reviews)
+		if err != nil {
+			return nil, err
+		}
+
+		return reviews, nil
+	default:
+		return nil, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+	}
+}
+
+// Pending review requests take precedence over approvals, as they are
+// removed once requested reviewers submit their reviews.
+func (g *GitHub) ChangeRequestStatus(projectPath string, number int) (provider.ChangeRequestStatus, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath + "/pulls/" + fmt.Sprint(number))
+	if err != nil {
+		return provider.ChangeRequestStatus{}, err
+	}
+
+	var pr PullRequestResponse
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ChangeRequestStatus{}, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return provider.ChangeRequestStatus{}, provider.ErrNotFound
+	case http.StatusOK:
+		err = json.Unmarshal(resp.Body, &pr)
+		if err != nil {
+			return provider.ChangeRequestStatus{}, err
+		}
+	default:
+		return provider.ChangeRequestStatus{}, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+	}
+
+	reviews, err := g.reviews(projectPath, number)
+	if err != nil {
+		return provider.ChangeRequestStatus{}, err
+	}
+
+	review, approvals := reviewState(reviews, len(pr.RequestedReviewers)+len(pr.RequestedTeams) > 0)
+
+	status := provider.ChangeRequestStatus{
+		ChangeRequest: pr.changeRequest(),
+		Draft:         pr.Draft,
+		Author:        pr.User.Login,
+		TargetBranch:  pr.Base.Ref,
+		Review:        review,
+		Approvals:     approvals,
+		Mergeable:     mergeableState(pr.MergeableState),
+	}
+
+	pipeline, err := g.FindPipeline(projectPath, pr.Head.Sha)
+	if err == nil {
+		status.Pipeline = &pipeline
+	} else if !errors.Is(err, provider.ErrNotFound) {
+		return provider.ChangeRequestStatus{}, err
+	}
+
+	return status, nil
+}
+
+func (g *GitHub) commitChecksURL(projectPath string, sha string) string {
+	return fmt.Sprintf("%s/%s/commit/%s/checks", g.webURL, projectPath, sha)
+}
+
+func (g *GitHub) ChecksURL(changeRequest provider.ChangeRequest) string {
+	return changeRequest.URL + "/checks"
+}
+
+type RepositoryResponse struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+	Fork          bool   `json:"fork"`
+	Parent        *struct {
+		FullName string `json:"full_name"`
+	} `json:"parent"`
+}
+
+// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#get-a-repository
+func (g *GitHub) repository(projectPath string) (RepositoryResponse, error) {
+	resp, err := g.get(g.apiURL + "/repos/" + projectPath)
+	if err != nil {
+		return RepositoryResponse{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return RepositoryResponse{}, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return RepositoryResponse{}, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var repository RepositoryResponse
+		err = json.Unmarshal(resp.Body, &repository)
+		if err != nil {
+			return RepositoryResponse{}, err
+		}
+
+		return repository, nil
+	default:
+		return RepositoryResponse{}, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
+	}
+}
+
+func (g *GitHub) ForkParent(projectPath string) (string, error) {
+	repository, err := g.repository(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	if !repository.Fork || repository.Parent == nil {
+		return "", nil
+	}
+
+	return repository.Parent.FullName, nil
+}
+
+func (g *GitHub) FindForkChangeRequest(parentPath string, forkPath string, branch string) (provider.ChangeRequest, error) {
+	return g.findPullRequest(parentPath, forkPath, branch)
+}
+
+// Compare page of the parent repository accepts head branch of a fork in
+// "owner:branch" form.
+func (g *GitHub) ForkCreateURL(parentPath string, forkPath string, branch string) (string, error) {
+	repository, err := g.repository(parentPath)
+	if err != nil {
+		return "", err
+	}
+
+	forkOwner := strings.Split(forkPath, "/")[0]
+	return fmt.Sprintf("%s/%s/compare/%s...%s:%s?expand=1", g.webURL, parentPath,
+		provider.EscapePath(repository.DefaultBranch), forkOwner, provider.EscapePath(branch)), nil
+}
+
+func (g *GitHub) getRemoteBranches(projectPath string) ([]string, error) {
+	url := g.apiURL + "/repos/" + projectPath + "/git/refs/heads"
+
+	resp, err := g.get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
 	case http.StatusOK:
 		var branches []struct {
 			Ref string `json:"ref"`
@@ -138,25 +683,82 @@ func GetRemoteBranches(projectPath string, token string) ([]string, error) {
 	}
 }
 
+func (g *GitHub) BranchExists(projectPath string, branch string) (bool, error) {
+	branches, err := g.getRemoteBranches(projectPath)
+	if err != nil {
+		return false, err
+	}
+
+	for _, b := range branches {
+		if b == branch {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // https://docs.github.com/en/rest/pulls/pulls?apiVersion=2022-11-28#list-pull-requests
-func ListOpenPullRequests(projectPath string, token string) ([]PullRequestResponse, error) {
-	url := "https://api.github.com/repos/" + projectPath + "/pulls?state=open&sort=updated&direction=desc"
-	resp, err := apiGet(url, token)
+func (g *GitHub) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	url := g.apiURL + "/repos/" + projectPath + "/pulls?state=open&sort=updated&direction=desc"
+	resp, err := g.get(url)
 	if err != nil {
 		return nil, err
 	}
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
+		return nil, provider.ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
 	case http.StatusOK:
 		var pullRequests []PullRequestResponse
 		err = json.Unmarshal(resp.Body, &pullRequests)
 		if err != nil {
 			return nil, err
 		}
-		return pullRequests, nil
+
+		var changeRequests []provider.ChangeRequest
+		for _, pr := range pullRequests {
+			changeRequests = append(changeRequests, pr.changeRequest())
+		}
+
+		return changeRequests, nil
 	default:
 		return nil, errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(resp.Body))
 	}
 }
+
+func (g *GitHub) CreateURL(projectPath string, branch string) string {
+	return fmt.Sprintf("%s/%s/pull/new/%s", g.webURL, projectPath, branch)
+}
+
+// Blob page redirects to the tree page for directories.
+func (g *GitHub) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	url := fmt.Sprintf("%s/%s/blob/%s/%s", g.webURL, projectPath, provider.EscapePath(revision.Name), provider.EscapePath(path))
+	if lines.Start > 0 {
+		url += fmt.Sprintf("#L%d", lines.Start)
+	}
+	if lines.End > lines.Start {
+		url += fmt.Sprintf("-L%d", lines.End)
+	}
+
+	return url
+}
+
+func (g *GitHub) CommitURL(projectPath string, sha string) string {
+	return fmt.Sprintf("%s/%s/commit/%s", g.webURL, projectPath, sha)
+}
+
+// Release page shows the tag also when no release was published for it.
+func (g *GitHub) TagURL(projectPath string, tag string) string {
+	return fmt.Sprintf("%s/%s/releases/tag/%s", g.webURL, projectPath, provider.EscapePath(tag))
+}
+
+func (g *GitHub) CompareURL(projectPath string, base provider.Revision, head provider.Revision) string {
+	return fmt.Sprintf("%s/%s/compare/%s...%s", g.webURL, projectPath, provider.EscapePath(base.Name), provider.EscapePath(head.Name))
+}
+
+func (g *GitHub) HomeURL(projectPath string) string {
+	return g.webURL + "/" + projectPath
+}
@@ -0,0 +1,228 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/wowu/pro/provider"
+)
+
+func review(login string, state string) ReviewResponse {
+	var r ReviewResponse
+	r.User.Login = login
+	r.State = state
+	return r
+}
+
+func TestReviewState(t *testing.T) {
+	tests := []struct {
+		name            string
+		reviews         []ReviewResponse
+		reviewRequested bool
+		wantReview      string
+		wantApprovals   int
+	}{
+		{
+			name:       "no reviews",
+			wantReview: provider.ReviewNone,
+		},
+		{
+			name:            "review requested without reviews",
+			reviewRequested: true,
+			wantReview:      provider.ReviewRequired,
+		},
+		{
+			name:          "approved",
+			reviews:       []ReviewResponse{review("a", "APPROVED"), review("b", "APPROVED")},
+			wantReview:    provider.ReviewApproved,
+			wantApprovals: 2,
+		},
+		{
+			name:          "approved after requesting changes",
+			reviews:       []ReviewResponse{review("a", "CHANGES_REQUESTED"), review("a", "APPROVED")},
+			wantReview:    provider.ReviewApproved,
+			wantApprovals: 1,
+		},
+		{
+			name:          "changes requested after approving",
+			reviews:       []ReviewResponse{review("a", "APPROVED"), review("a", "CHANGES_REQUESTED"), review("b", "APPROVED")},
+			wantReview:    provider.ReviewChangesRequested,
+			wantApprovals: 1,
+		},
+		{
+			name:          "several approvals of one reviewer",
+			reviews:       []ReviewResponse{review("a", "APPROVED"), review("a", "APPROVED")},
+			wantReview:    provider.ReviewApproved,
+			wantApprovals: 1,
+		},
+		{
+			name:          "comment after approving",
+			reviews:       []ReviewResponse{review("a", "APPROVED"), review("a", "COMMENTED")},
+			wantReview:    provider.ReviewApproved,
+			wantApprovals: 1,
+		},
+		{
+			name:       "dismissed approval",
+			reviews:    []ReviewResponse{review("a", "APPROVED"), review("a", "DISMISSED")},
+			wantReview: provider.ReviewNone,
+		},
+		{
+			name:       "dismissed request for changes",
+			reviews:    []ReviewResponse{review("a", "CHANGES_REQUESTED"), review("a", "DISMISSED")},
+			wantReview: provider.ReviewNone,
+		},
+		{
+			name:            "review requested from another reviewer",
+			reviews:         []ReviewResponse{review("a", "APPROVED")},
+			reviewRequested: true,
+			wantReview:      provider.ReviewRequired,
+			wantApprovals:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotReview, gotApprovals := reviewState(tt.reviews, tt.reviewRequested)
+			if gotReview != tt.wantReview || gotApprovals != tt.wantApprovals {
+				t.Errorf("reviewState() = %q, %d, want %q, %d", gotReview, gotApprovals, tt.wantReview, tt.wantApprovals)
+			}
+		})
+	}
+}
+
+func TestMergeableState(t *testing.T) {
+	tests := []struct {
+		state string
+		want  string
+	}{
+		{state: "clean", want: provider.MergeableYes},
+		{state: "unstable", want: provider.MergeableYes},
+		{state: "has_hooks", want: provider.MergeableYes},
+		{state: "dirty", want: provider.MergeableConflicts},
+		{state: "blocked", want: provider.MergeableBlocked},
+		{state: "behind", want: provider.MergeableBlocked},
+		{state: "draft", want: provider.MergeableBlocked},
+		{state: "unknown", want: provider.MergeableUnknown},
+		{state: "", want: provider.MergeableUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			if got := mergeableState(tt.state); got != tt.want {
+				t.Errorf("mergeableState(%q) = %q, want %q", tt.state, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineStatus(t *testing.T) {
+	tests := []struct {
+		status     string
+		conclusion string
+		want       string
+	}{
+		{status: "queued", want: provider.PipelinePending},
+		{status: "waiting", want: provider.PipelinePending},
+		{status: "requested", want: provider.PipelinePending},
+		{status: "pending", want: provider.PipelinePending},
+		{status: "in_progress", want: provider.PipelineRunning},
+		{status: "completed", conclusion: "success", want: provider.PipelineSuccess},
+		{status: "completed", conclusion: "neutral", want: provider.PipelineSuccess},
+		{status: "completed", conclusion: "failure", want: provider.PipelineFailed},
+		{status: "completed", conclusion: "timed_out", want: provider.PipelineFailed},
+		{status: "completed", conclusion: "action_required", want: provider.PipelineFailed},
+		{status: "completed", conclusion: "startup_failure", want: provider.PipelineFailed},
+		{status: "completed", conclusion: "cancelled", want: provider.PipelineCanceled},
+		{status: "completed", conclusion: "skipped", want: provider.PipelineSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status+" "+tt.conclusion, func(t *testing.T) {
+			if got := pipelineStatus(tt.status, tt.conclusion); got != tt.want {
+				t.Errorf("pipelineStatus(%q, %q) = %q, want %q", tt.status, tt.conclusion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinedStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     string
+	}{
+		{
+			name:     "single",
+			statuses: []string{provider.PipelineSuccess},
+			want:     provider.PipelineSuccess,
+		},
+		{
+			name:     "failure wins over pending",
+			statuses: []string{provider.PipelinePending, provider.PipelineFailed},
+			want:     provider.PipelineFailed,
+		},
+		{
+			name:     "failure wins over running",
+			statuses: []string{provider.PipelineRunning, provider.PipelineFailed, provider.PipelineSuccess},
+			want:     provider.PipelineFailed,
+		},
+		{
+			name:     "running wins over pending",
+			statuses: []string{provider.PipelinePending, provider.PipelineRunning},
+			want:     provider.PipelineRunning,
+		},
+		{
+			name:     "pending wins over success",
+			statuses: []string{provider.PipelineSuccess, provider.PipelinePending},
+			want:     provider.PipelinePending,
+		},
+		{
+			name:     "failure wins over success",
+			statuses: []string{provider.PipelineSuccess, provider.PipelineFailed, provider.PipelineSuccess},
+			want:     provider.PipelineFailed,
+		},
+		{
+			name:     "failure wins over canceled",
+			statuses: []string{provider.PipelineCanceled, provider.PipelineFailed},
+			want:     provider.PipelineFailed,
+		},
+		{
+			name:     "skipped ones are ignored",
+			statuses: []string{provider.PipelineSkipped, provider.PipelineSuccess},
+			want:     provider.PipelineSuccess,
+		},
+		{
+			name:     "all skipped",
+			statuses: []string{provider.PipelineSkipped, provider.PipelineSkipped},
+			want:     provider.PipelineSkipped,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combinedStatus(tt.statuses); got != tt.want {
+				t.Errorf("combinedStatus(%v) = %q, want %q", tt.statuses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestRuns(t *testing.T) {
+	runs := []WorkflowRunResponse{
+		{Name: "build", WorkflowID: 1, HtmlURL: "run/4"},
+		{Name: "lint", WorkflowID: 2, HtmlURL: "run/3"},
+		{Name: "build", WorkflowID: 1, HtmlURL: "run/2"},
+		{Name: "lint", WorkflowID: 2, HtmlURL: "run/1"},
+	}
+
+	got := latestRuns(runs)
+
+	want := []string{"run/4", "run/3"}
+	if len(got) != len(want) {
+		t.Fatalf("latestRuns() returned %d runs, want %d", len(got), len(want))
+	}
+	for i, run := range got {
+		if run.HtmlURL != want[i] {
+			t.Errorf("latestRuns()[%d] = %q, want %q", i, run.HtmlURL, want[i])
+		}
+	}
+}
@@ -3,15 +3,57 @@ package gitlab
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+
+	"github.com/wowu/pro/provider"
 )
 
-var ErrUnauthorized = errors.New("unauthorized")
-var ErrProjectNotFound = errors.New("project not found")
-var ErrMergeRequestNotFound = errors.New("merge request not found")
-var ErrTokenExpired = errors.New("token expired")
+func init() {
+	provider.Register(provider.Registration{
+		Info:  info,
+		Hosts: []string{"gitlab.com"},
+		New:   New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "gitlab",
+	Title:       "GitLab",
+	RequestName: "merge request",
+	RefPrefix:   "!",
+	TokenScopes: "read_api",
+	TokenHint:   "It's recommended to leave \"Expiration date\" blank.",
+}
+
+type GitLab struct {
+	host   string
+	apiURL string
+	webURL string
+	token  string
+
+	// Projects fetched from the API by path, forks need the same ones several times
+	projects map[string]ProjectResponse
+}
+
+// New returns GitLab provider for given instance. Base URLs default to
+// https://<host> and https://<host>/api/v4.
+func New(instance provider.Instance) provider.Provider {
+	webURL := strings.TrimSuffix(instance.WebURL, "/")
+	if webURL == "" {
+		webURL = "https://" + instance.Host
+	}
+
+	apiURL := strings.TrimSuffix(instance.APIURL, "/")
+	if apiURL == "" {
+		apiURL = webURL + "/api/v4"
+	}
+
+	return &GitLab{host: instance.Host, apiURL: apiURL, webURL: webURL, token: instance.Token, projects: map[string]ProjectResponse{}}
+}
 
 type ApiResponse struct {
 	StatusCode int
@@ -40,12 +82,47 @@ func apiGet(url string, token string) (ApiResponse, error) {
 	return ApiResponse{resp.StatusCode, body}, nil
 }
 
+func (g *GitLab) get(url string) (ApiResponse, error) {
+	if g.token == "" {
+		return ApiResponse{}, provider.ErrNoToken
+	}
+
+	return apiGet(url, g.token)
+}
+
+// Distinguishes expired tokens from invalid ones in 401 response.
+func unauthorizedError(resp ApiResponse) error {
+	var body map[string]interface{}
+	err := json.Unmarshal(resp.Body, &body)
+	if err != nil {
+		return err
+	}
+
+	if body["error_description"] == "Token is expired. You can either do re-authorization or token refresh." {
+		return provider.ErrTokenExpired
+	}
+
+	return provider.ErrUnauthorized
+}
+
+func (g *GitLab) Info() provider.Info {
+	return info
+}
+
+func (g *GitLab) Host() string {
+	return g.host
+}
+
+func (g *GitLab) TokenURL() string {
+	return g.webURL + "/-/user_settings/personal_access_tokens?name=pro+cli&scopes=read_api"
+}
+
 type UserResponse struct {
 	ID int `json:"id"`
 }
 
-func User(token string) (UserResponse, error) {
-	url := "https://gitlab.com/api/v4/user"
+func (g *GitLab) user(token string) (UserResponse, error) {
+	url := g.apiURL + "/user"
 	resp, err := apiGet(url, token)
 	if err != nil {
 		return UserResponse{}, err
@@ -53,7 +130,7 @@ func User(token string) (UserResponse, error) {
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		return UserResponse{}, ErrUnauthorized
+		return UserResponse{}, provider.ErrUnauthorized
 	case http.StatusOK:
 		var user UserResponse
 		err = json.Unmarshal(resp.Body, &user)
@@ -67,64 +144,425 @@ func User(token string) (UserResponse, error) {
 	}
 }
 
+func (g *GitLab) ValidateToken(token string) error {
+	_, err := g.user(token)
+	return err
+}
+
 type MergeRequestResponse struct {
 	ID           int    `json:"id"`
 	IID          int    `json:"iid"`
 	Title        string `json:"title"`
 	State        string `json:"state"`
 	SourceBranch string `json:"source_branch"`
-	WebUrl       string `json:"web_url"`
+	TargetBranch string `json:"target_branch"`
+	Draft        bool   `json:"draft"`
+	Author       struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	DetailedMergeStatus string            `json:"detailed_merge_status"`
+	Sha                 string            `json:"sha"`
+	HeadPipeline        *PipelineResponse `json:"head_pipeline"`
+	WebUrl              string            `json:"web_url"`
 }
 
-func FindMergeRequest(projectPath string, token string, branch string) (MergeRequestResponse, error) {
-	url := "https://gitlab.com/api/v4/projects/" + url.QueryEscape(projectPath) + "/merge_requests?state=opened&source_branch=" + url.QueryEscape(branch)
-	resp, err := apiGet(url, token)
+func (mr MergeRequestResponse) changeRequest() provider.ChangeRequest {
+	// Locked merge requests are open ones being merged
+	state := provider.StateOpen
+	switch mr.State {
+	case "merged":
+		state = provider.StateMerged
+	case "closed":
+		state = provider.StateClosed
+	}
+
+	return provider.ChangeRequest{
+		Number: mr.IID,
+		Title:  mr.Title,
+		Branch: mr.SourceBranch,
+		URL:    mr.WebUrl,
+		State:  state,
+	}
+}
+
+func (g *GitLab) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	return g.findMergeRequest(projectPath, branch, 0)
+}
+
+// Returns open merge request from given branch. Merge requests opened from a
+// fork are matched by ID of the source project, unless it's 0.
+// https://docs.gitlab.com/ee/api/merge_requests.html#list-project-merge-requests
+func (g *GitLab) findMergeRequest(projectPath string, branch string, sourceProjectID int) (provider.ChangeRequest, error) {
+	url := g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/merge_requests?state=opened&source_branch=" + url.QueryEscape(branch)
+	if sourceProjectID != 0 {
+		url += "&source_project_id=" + fmt.Sprint(sourceProjectID)
+	}
+
+	resp, err := g.get(url)
 	if err != nil {
-		return MergeRequestResponse{}, err
+		return provider.ChangeRequest{}, err
 	}
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		var body map[string]interface{}
-		err = json.Unmarshal(resp.Body, &body)
+		return provider.ChangeRequest{}, unauthorizedError(resp)
+	case http.StatusNotFound:
+		return provider.ChangeRequest{}, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var mergeRequests []MergeRequestResponse
+		err = json.Unmarshal(resp.Body, &mergeRequests)
 		if err != nil {
-			return MergeRequestResponse{}, err
+			return provider.ChangeRequest{}, err
 		}
 
-		if body["error_description"] == "Token is expired. You can either do re-authorization or token refresh." {
-			return MergeRequestResponse{}, ErrTokenExpired
-		} else {
-			return MergeRequestResponse{}, ErrUnauthorized
+		if len(mergeRequests) == 0 {
+			return provider.ChangeRequest{}, provider.ErrNotFound
 		}
+
+		return mergeRequests[0].changeRequest(), nil
+	default:
+		return provider.ChangeRequest{}, errors.New("unknown response code")
+	}
+}
+
+// https://docs.gitlab.com/ee/api/merge_requests.html#get-single-mr
+func (g *GitLab) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	resp, err := g.get(g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/merge_requests/" + fmt.Sprint(number))
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ChangeRequest{}, unauthorizedError(resp)
+	case http.StatusNotFound:
+		if strings.Contains(string(resp.Body), "Project Not Found") {
+			return provider.ChangeRequest{}, provider.ErrProjectNotFound
+		}
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	case http.StatusOK:
+		var mergeRequest MergeRequestResponse
+		err = json.Unmarshal(resp.Body, &mergeRequest)
+		if err != nil {
+			return provider.ChangeRequest{}, err
+		}
+
+		return mergeRequest.changeRequest(), nil
+	default:
+		return provider.ChangeRequest{}, errors.New("unknown response code")
+	}
+}
+
+// https://docs.gitlab.com/ee/api/commits.html#list-merge-requests-associated-with-a-commit
+func (g *GitLab) ChangeRequestsForCommit(projectPath string, sha string) ([]provider.ChangeRequest, error) {
+	resp, err := g.get(g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/repository/commits/" + sha + "/merge_requests")
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, unauthorizedError(resp)
 	case http.StatusNotFound:
-		return MergeRequestResponse{}, ErrProjectNotFound
+		// Same status is returned for commits which were not pushed
+		if strings.Contains(string(resp.Body), "Commit Not Found") {
+			return nil, provider.ErrNotFound
+		}
+		return nil, provider.ErrProjectNotFound
 	case http.StatusOK:
 		var mergeRequests []MergeRequestResponse
 		err = json.Unmarshal(resp.Body, &mergeRequests)
 		if err != nil {
-			return MergeRequestResponse{}, err
+			return nil, err
 		}
 
-		if len(mergeRequests) == 0 {
-			return MergeRequestResponse{}, ErrMergeRequestNotFound
+		var changeRequests []provider.ChangeRequest
+		for _, mr := range mergeRequests {
+			changeRequests = append(changeRequests, mr.changeRequest())
 		}
 
-		return mergeRequests[0], nil
+		return changeRequests, nil
 	default:
-		return MergeRequestResponse{}, errors.New("unknown response code")
+		return nil, errors.New("unknown response code")
 	}
 }
 
-func GetRemoteBranches(projectPath string, token string) ([]string, error) {
-	url := "https://gitlab.com/api/v4/projects/" + url.QueryEscape(projectPath) + "/repository/branches"
-	resp, err := apiGet(url, token)
+type PipelineResponse struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	WebUrl string `json:"web_url"`
+}
+
+func (p PipelineResponse) pipeline() provider.Pipeline {
+	status := provider.PipelinePending
+	switch p.Status {
+	case "running":
+		status = provider.PipelineRunning
+	case "success":
+		status = provider.PipelineSuccess
+	case "failed":
+		status = provider.PipelineFailed
+	case "canceled":
+		status = provider.PipelineCanceled
+	case "skipped":
+		status = provider.PipelineSkipped
+	}
+
+	name := p.Name
+	if name == "" {
+		name = fmt.Sprintf("#%d", p.ID)
+	}
+
+	return provider.Pipeline{Name: name, Status: status, URL: p.WebUrl}
+}
+
+// Pipelines of merge requests run for the same commit as branch pipelines, so
+// they are found too.
+// https://docs.gitlab.com/ee/api/pipelines.html#list-project-pipelines
+func (g *GitLab) FindPipeline(projectPath string, sha string) (provider.Pipeline, error) {
+	resp, err := g.get(g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/pipelines?order_by=id&sort=desc&per_page=1&sha=" + sha)
+	if err != nil {
+		return provider.Pipeline{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.Pipeline{}, unauthorizedError(resp)
+	case http.StatusNotFound:
+		return provider.Pipeline{}, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var pipelines []PipelineResponse
+		err = json.Unmarshal(resp.Body, &pipelines)
+		if err != nil {
+			return provider.Pipeline{}, err
+		}
+
+		if len(pipelines) == 0 {
+			return provider.Pipeline{}, provider.ErrNotFound
+		}
+
+		return pipelines[0].pipeline(), nil
+	default:
+		return provider.Pipeline{}, errors.New("unknown response code")
+	}
+}
+
+func (g *GitLab) ChecksURL(changeRequest provider.ChangeRequest) string {
+	return changeRequest.URL + "/pipelines"
+}
+
+type ApprovalsResponse struct {
+	ApprovalsLeft int `json:"approvals_left"`
+	ApprovedBy    []struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"approved_by"`
+}
+
+// Returns merge state of a merge request given its detailed_merge_status.
+// Statuses other than listed ones are reasons blocking the merge.
+// https://docs.gitlab.com/ee/api/merge_requests.html#merge-status
+func mergeableState(status string) string {
+	switch status {
+	case "mergeable":
+		return provider.MergeableYes
+	case "conflict", "need_rebase":
+		return provider.MergeableConflicts
+	case "", "checking", "unchecked", "approvals_syncing", "preparing":
+		return provider.MergeableUnknown
+	default:
+		return provider.MergeableBlocked
+	}
+}
+
+// https://docs.gitlab.com/ee/api/merge_request_approvals.html#get-configuration-1
+func (g *GitLab) approvals(projectPath string, number int) (ApprovalsResponse, error) {
+	resp, err := g.get(g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/merge_requests/" + fmt.Sprint(number) + "/approvals")
+	if err != nil {
+		return ApprovalsResponse{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return ApprovalsResponse{}, unauthorizedError(resp)
+	case http.StatusNotFound:
+		return ApprovalsResponse{}, provider.ErrNotFound
+	case http.StatusOK:
+		var approvals ApprovalsResponse
+		err = json.Unmarshal(resp.Body, &approvals)
+		if err != nil {
+			return ApprovalsResponse{}, err
+		}
+
+		return approvals, nil
+	default:
+		return ApprovalsResponse{}, errors.New("unknown response code")
+	}
+}
+
+// Review is required while approval rules are not satisfied. Requested
+// changes are reported through merge status, as approvals API doesn't list
+// them.
+func (g *GitLab) ChangeRequestStatus(projectPath string, number int) (provider.ChangeRequestStatus, error) {
+	resp, err := g.get(g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/merge_requests/" + fmt.Sprint(number))
+	if err != nil {
+		return provider.ChangeRequestStatus{}, err
+	}
+
+	var mr MergeRequestResponse
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return provider.ChangeRequestStatus{}, unauthorizedError(resp)
+	case http.StatusNotFound:
+		if strings.Contains(string(resp.Body), "Project Not Found") {
+			return provider.ChangeRequestStatus{}, provider.ErrProjectNotFound
+		}
+		return provider.ChangeRequestStatus{}, provider.ErrNotFound
+	case http.StatusOK:
+		err = json.Unmarshal(resp.Body, &mr)
+		if err != nil {
+			return provider.ChangeRequestStatus{}, err
+		}
+	default:
+		return provider.ChangeRequestStatus{}, errors.New("unknown response code")
+	}
+
+	approvals, err := g.approvals(projectPath, number)
+	if err != nil {
+		return provider.ChangeRequestStatus{}, err
+	}
+
+	review := provider.ReviewNone
+	switch {
+	case mr.DetailedMergeStatus == "requested_changes":
+		review = provider.ReviewChangesRequested
+	case approvals.ApprovalsLeft > 0:
+		review = provider.ReviewRequired
+	case len(approvals.ApprovedBy) > 0:
+		review = provider.ReviewApproved
+	}
+
+	status := provider.ChangeRequestStatus{
+		ChangeRequest: mr.changeRequest(),
+		Draft:         mr.Draft,
+		Author:        mr.Author.Username,
+		TargetBranch:  mr.TargetBranch,
+		Review:        review,
+		Approvals:     len(approvals.ApprovedBy),
+		Mergeable:     mergeableState(mr.DetailedMergeStatus),
+	}
+
+	// Head pipeline is missing for merge requests without pipelines run for
+	// the merge request itself
+	if mr.HeadPipeline != nil {
+		pipeline := mr.HeadPipeline.pipeline()
+		status.Pipeline = &pipeline
+		return status, nil
+	}
+
+	pipeline, err := g.FindPipeline(projectPath, mr.Sha)
+	if err == nil {
+		status.Pipeline = &pipeline
+	} else if !errors.Is(err, provider.ErrNotFound) {
+		return provider.ChangeRequestStatus{}, err
+	}
+
+	return status, nil
+}
+
+type ProjectResponse struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+	ForkedFromProject *struct {
+		ID                int    `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"forked_from_project"`
+}
+
+// https://docs.gitlab.com/ee/api/projects.html#get-single-project
+func (g *GitLab) project(projectPath string) (ProjectResponse, error) {
+	if project, ok := g.projects[projectPath]; ok {
+		return project, nil
+	}
+
+	resp, err := g.get(g.apiURL + "/projects/" + url.QueryEscape(projectPath))
+	if err != nil {
+		return ProjectResponse{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return ProjectResponse{}, unauthorizedError(resp)
+	case http.StatusNotFound:
+		return ProjectResponse{}, provider.ErrProjectNotFound
+	case http.StatusOK:
+		var project ProjectResponse
+		err = json.Unmarshal(resp.Body, &project)
+		if err != nil {
+			return ProjectResponse{}, err
+		}
+
+		g.projects[projectPath] = project
+		return project, nil
+	default:
+		return ProjectResponse{}, errors.New("unknown response code")
+	}
+}
+
+func (g *GitLab) ForkParent(projectPath string) (string, error) {
+	project, err := g.project(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	if project.ForkedFromProject == nil {
+		return "", nil
+	}
+
+	return project.ForkedFromProject.PathWithNamespace, nil
+}
+
+func (g *GitLab) FindForkChangeRequest(parentPath string, forkPath string, branch string) (provider.ChangeRequest, error) {
+	fork, err := g.project(forkPath)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	return g.findMergeRequest(parentPath, branch, fork.ID)
+}
+
+// Merge requests from a fork are created in the fork, with the parent
+// selected as target project.
+func (g *GitLab) ForkCreateURL(parentPath string, forkPath string, branch string) (string, error) {
+	fork, err := g.project(forkPath)
+	if err != nil {
+		return "", err
+	}
+
+	parent, err := g.project(parentPath)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s&merge_request%%5Bsource_project_id%%5D=%d&merge_request%%5Btarget_project_id%%5D=%d",
+		g.CreateURL(forkPath, branch), fork.ID, parent.ID), nil
+}
+
+func (g *GitLab) getRemoteBranches(projectPath string) ([]string, error) {
+	url := g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/repository/branches"
+	resp, err := g.get(url)
 	if err != nil {
 		return nil, err
 	}
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
+		return nil, unauthorizedError(resp)
+	case http.StatusNotFound:
+		return nil, provider.ErrProjectNotFound
 	case http.StatusOK:
 		var branches []struct {
 			Name string `json:"name"`
@@ -145,36 +583,79 @@ func GetRemoteBranches(projectPath string, token string) ([]string, error) {
 	}
 }
 
-func ListOpenMergeRequests(projectPath string, token string) ([]MergeRequestResponse, error) {
-	url := "https://gitlab.com/api/v4/projects/" + url.QueryEscape(projectPath) + "/merge_requests?state=opened"
-	resp, err := apiGet(url, token)
+func (g *GitLab) BranchExists(projectPath string, branch string) (bool, error) {
+	branches, err := g.getRemoteBranches(projectPath)
+	if err != nil {
+		return false, err
+	}
+
+	for _, b := range branches {
+		if b == branch {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (g *GitLab) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	url := g.apiURL + "/projects/" + url.QueryEscape(projectPath) + "/merge_requests?state=opened"
+	resp, err := g.get(url)
 	if err != nil {
 		return nil, err
 	}
 
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
-		var body map[string]interface{}
-		err = json.Unmarshal(resp.Body, &body)
-		if err != nil {
-			return nil, err
-		}
-
-		if body["error_description"] == "Token is expired. You can either do re-authorization or token refresh." {
-			return nil, ErrTokenExpired
-		} else {
-			return nil, ErrUnauthorized
-		}
+		return nil, unauthorizedError(resp)
 	case http.StatusNotFound:
-		return nil, ErrProjectNotFound
+		return nil, provider.ErrProjectNotFound
 	case http.StatusOK:
 		var mergeRequests []MergeRequestResponse
 		err = json.Unmarshal(resp.Body, &mergeRequests)
 		if err != nil {
 			return nil, err
 		}
-		return mergeRequests, nil
+
+		var changeRequests []provider.ChangeRequest
+		for _, mr := range mergeRequests {
+			changeRequests = append(changeRequests, mr.changeRequest())
+		}
+
+		return changeRequests, nil
 	default:
 		return nil, errors.New("unknown response code")
 	}
 }
+
+func (g *GitLab) CreateURL(projectPath string, branch string) string {
+	return fmt.Sprintf("%s/%s/merge_requests/new?merge_request%%5Bsource_branch%%5D=%s", g.webURL, projectPath, branch)
+}
+
+func (g *GitLab) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	url := fmt.Sprintf("%s/%s/-/blob/%s/%s", g.webURL, projectPath, provider.EscapePath(revision.Name), provider.EscapePath(path))
+	if lines.Start > 0 {
+		url += fmt.Sprintf("#L%d", lines.Start)
+	}
+	if lines.End > lines.Start {
+		url += fmt.Sprintf("-%d", lines.End)
+	}
+
+	return url
+}
+
+func (g *GitLab) CommitURL(projectPath string, sha string) string {
+	return fmt.Sprintf("%s/%s/-/commit/%s", g.webURL, projectPath, sha)
+}
+
+func (g *GitLab) TagURL(projectPath string, tag string) string {
+	return fmt.Sprintf("%s/%s/-/tags/%s", g.webURL, projectPath, provider.EscapePath(tag))
+}
+
+func (g *GitLab) CompareURL(projectPath string, base provider.Revision, head provider.Revision) string {
+	return fmt.Sprintf("%s/%s/-/compare/%s...%s", g.webURL, projectPath, provider.EscapePath(base.Name), provider.EscapePath(head.Name))
+}
+
+func (g *GitLab) HomeURL(projectPath string) string {
+	return g.webURL + "/" + projectPath
+}
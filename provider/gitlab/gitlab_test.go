@@ -0,0 +1,36 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/wowu/pro/provider"
+)
+
+func TestMergeableState(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{status: "mergeable", want: provider.MergeableYes},
+		{status: "conflict", want: provider.MergeableConflicts},
+		{status: "need_rebase", want: provider.MergeableConflicts},
+		{status: "not_approved", want: provider.MergeableBlocked},
+		{status: "draft_status", want: provider.MergeableBlocked},
+		{status: "ci_must_pass", want: provider.MergeableBlocked},
+		{status: "discussions_not_resolved", want: provider.MergeableBlocked},
+		{status: "requested_changes", want: provider.MergeableBlocked},
+		{status: "checking", want: provider.MergeableUnknown},
+		{status: "unchecked", want: provider.MergeableUnknown},
+		{status: "approvals_syncing", want: provider.MergeableUnknown},
+		{status: "preparing", want: provider.MergeableUnknown},
+		{status: "", want: provider.MergeableUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := mergeableState(tt.status); got != tt.want {
+				t.Errorf("mergeableState(%q) = %q, want %q", tt.status, got, tt.want)
+			}
+		})
+	}
+}
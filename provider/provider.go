@@ -0,0 +1,278 @@
+package provider
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrNoToken         = errors.New("token is not set")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrTokenExpired    = errors.New("token expired")
+	ErrProjectNotFound = errors.New("project not found")
+	ErrNotFound        = errors.New("not found")
+)
+
+// Info describes a provider kind and the wording used for it in messages.
+type Info struct {
+	// Name is the identifier used on the command line, e.g. "github" in `pro auth github`.
+	Name string
+
+	// Title is the human readable name, e.g. "GitHub".
+	Title string
+
+	// RequestName is how the provider calls change requests, e.g. "pull request".
+	RequestName string
+
+	// RefPrefix is prepended to change request numbers, e.g. "#" or "!".
+	RefPrefix string
+
+	// TokenScopes lists the scopes the access token needs.
+	TokenScopes string
+
+	// TokenHint is an additional hint shown when asking for the token.
+	TokenHint string
+}
+
+// Instance is a concrete installation of a provider.
+type Instance struct {
+	Host string
+
+	// APIURL and WebURL are base URLs without trailing slash. They may be
+	// empty, in which case the provider derives them from Host.
+	APIURL string
+	WebURL string
+
+	Token string
+}
+
+// States of change requests, common to all providers.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+	StateMerged = "merged"
+)
+
+// ChangeRequest is a pull request, merge request or its equivalent.
+type ChangeRequest struct {
+	Number int
+	Title  string
+	Branch string
+	URL    string
+
+	// State is one of StateOpen, StateClosed and StateMerged.
+	State string
+}
+
+// Provider talks to a single git hosting service.
+//
+// Project paths are repository paths without leading slash and ".git" suffix,
+// e.g. "owner/repo" or "group/subgroup/repo".
+type Provider interface {
+	Info() Info
+
+	// Host returns the hostname of the instance the provider talks to.
+	Host() string
+
+	// FindChangeRequest returns the open change request for given branch or ErrNotFound.
+	FindChangeRequest(projectPath string, branch string) (ChangeRequest, error)
+
+	// GetChangeRequest returns the change request with given number in any state or ErrNotFound.
+	GetChangeRequest(projectPath string, number int) (ChangeRequest, error)
+
+	ListOpenChangeRequests(projectPath string) ([]ChangeRequest, error)
+
+	BranchExists(projectPath string, branch string) (bool, error)
+
+	// CreateURL returns the URL of the page creating a new change request for given branch.
+	CreateURL(projectPath string, branch string) string
+
+	HomeURL(projectPath string) string
+
+	// TokenURL returns the page where a new access token can be generated.
+	TokenURL() string
+
+	// ValidateToken checks given token against the API, returns ErrUnauthorized if it is invalid.
+	ValidateToken(token string) error
+}
+
+// Commit describes the commit HEAD points to.
+type Commit struct {
+	// Subject is the first line of the commit message.
+	Subject string
+
+	// ChangeID is the value of Change-Id trailer of the commit message, if any.
+	ChangeID string
+}
+
+// CommitMatcher is implemented by providers which match change requests by the
+// HEAD commit instead of the branch, e.g. Gerrit changes by Change-Id or
+// SourceHut patchsets by subject.
+type CommitMatcher interface {
+	// FindChangeRequestForCommit returns the change request matching given commit or ErrNotFound.
+	FindChangeRequestForCommit(projectPath string, commit Commit) (ChangeRequest, error)
+
+	// SubmitInstructions explains how to submit a change request targeting
+	// given branch through given git remote, and returns URL of a page with
+	// more details.
+	SubmitInstructions(projectPath string, remote string, branch string) (instructions string, url string)
+}
+
+// ForkProvider is implemented by providers supporting change requests opened
+// from a branch of a fork against its parent repository.
+type ForkProvider interface {
+	// ForkParent returns path of the project given project was forked from,
+	// or empty string if it is not a fork.
+	ForkParent(projectPath string) (string, error)
+
+	// FindForkChangeRequest returns the open change request from given branch
+	// of the fork to the parent project or ErrNotFound.
+	FindForkChangeRequest(parentPath string, forkPath string, branch string) (ChangeRequest, error)
+
+	// ForkCreateURL returns the URL of the page creating a new change request
+	// from given branch of the fork to the parent project.
+	ForkCreateURL(parentPath string, forkPath string, branch string) (string, error)
+}
+
+// CommitLookup is implemented by providers able to find change requests
+// containing given commit, used when HEAD is detached.
+type CommitLookup interface {
+	// ChangeRequestsForCommit returns change requests containing commit with
+	// given SHA, or ErrNotFound if the commit is unknown to the provider.
+	ChangeRequestsForCommit(projectPath string, sha string) ([]ChangeRequest, error)
+}
+
+// Kinds of revisions repository pages are shown at.
+const (
+	RevisionCommit = "commit"
+	RevisionBranch = "branch"
+	RevisionTag    = "tag"
+)
+
+// Revision is a commit, branch or tag repository pages are shown at.
+type Revision struct {
+	// Name is SHA of the commit, name of the branch or name of the tag.
+	Name string
+
+	// Kind is RevisionCommit, RevisionBranch or RevisionTag.
+	Kind string
+}
+
+// Lines is a range of lines of a file. Start is 0 when no line is selected,
+// End equals Start for a single line.
+type Lines struct {
+	Start int
+	End   int
+}
+
+// FileBrowser is implemented by providers showing repository files on web
+// pages, e.g. GitHub blob pages.
+type FileBrowser interface {
+	// FileURL returns URL of the page showing file at given path of the
+	// repository at given revision, with given lines highlighted.
+	FileURL(projectPath string, revision Revision, path string, lines Lines) string
+}
+
+// RevisionBrowser is implemented by providers showing commits and tags on web
+// pages.
+type RevisionBrowser interface {
+	// CommitURL returns URL of the page showing commit with given SHA.
+	CommitURL(projectPath string, sha string) string
+
+	// TagURL returns URL of the page showing given tag or its release.
+	TagURL(projectPath string, tag string) string
+}
+
+// Comparer is implemented by providers showing differences between two
+// revisions on a web page.
+type Comparer interface {
+	// CompareURL returns URL of the page showing changes from base to head.
+	CompareURL(projectPath string, base Revision, head Revision) string
+}
+
+// Statuses of CI pipelines, common to all providers.
+const (
+	PipelinePending  = "pending"
+	PipelineRunning  = "running"
+	PipelineSuccess  = "success"
+	PipelineFailed   = "failed"
+	PipelineCanceled = "canceled"
+	PipelineSkipped  = "skipped"
+)
+
+// Pipeline is a CI pipeline, workflow run or a set of checks run for a commit.
+type Pipeline struct {
+	// Name of the pipeline or workflow, e.g. "#1234" or "build".
+	Name string
+
+	// Status is one of Pipeline* constants.
+	Status string
+
+	URL string
+}
+
+// CIProvider is implemented by providers running CI pipelines for commits.
+type CIProvider interface {
+	// FindPipeline returns the latest pipeline run for commit with given SHA
+	// or ErrNotFound.
+	FindPipeline(projectPath string, sha string) (Pipeline, error)
+
+	// ChecksURL returns URL of the page of given change request listing its
+	// CI results.
+	ChecksURL(changeRequest ChangeRequest) string
+}
+
+// Review states of change requests, common to all providers.
+const (
+	ReviewNone             = "none"
+	ReviewRequired         = "review_required"
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+)
+
+// Merge states of change requests, common to all providers.
+const (
+	MergeableYes       = "mergeable"
+	MergeableConflicts = "conflicts"
+	MergeableBlocked   = "blocked"
+	MergeableUnknown   = "unknown"
+)
+
+// ChangeRequestStatus is a change request with details of its review, merge
+// and CI state.
+type ChangeRequestStatus struct {
+	ChangeRequest
+
+	Draft  bool
+	Author string
+
+	// TargetBranch is the branch the change request is merged to.
+	TargetBranch string
+
+	// Review is one of Review* constants.
+	Review string
+
+	// Approvals is the number of users who approved the change request.
+	Approvals int
+
+	// Mergeable is one of Mergeable* constants.
+	Mergeable string
+
+	// Pipeline is the latest CI pipeline of the change request, nil if there is none.
+	Pipeline *Pipeline
+}
+
+// StatusProvider is implemented by providers able to report details of a
+// change request.
+type StatusProvider interface {
+	// ChangeRequestStatus returns details of the change request with given
+	// number or ErrNotFound.
+	ChangeRequestStatus(projectPath string, number int) (ChangeRequestStatus, error)
+}
+
+// EscapePath escapes file path or branch name for use in URL path, keeping
+// slashes of names like "feature/x".
+func EscapePath(path string) string {
+	return strings.ReplaceAll(url.PathEscape(path), "%2F", "/")
+}
@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/wowu/pro/config"
+)
+
+var ErrUnknownHost = errors.New("unknown remote type")
+var ErrUnknownProvider = errors.New("unknown provider")
+var ErrHostRequired = errors.New("provider has no default host")
+
+// Registration binds a provider kind to the hosts it serves.
+type Registration struct {
+	Info Info
+
+	// Hosts served by the provider out of the box. The first one is the default
+	// host used when no repository is involved, e.g. in `pro auth`. Providers
+	// without hosts are available only for hosts declared in config.
+	Hosts []string
+
+	// New returns provider instance talking to given installation.
+	New func(instance Instance) Provider
+}
+
+var registrations []Registration
+
+// Register makes a provider kind available. It's meant to be called from init
+// function of the provider package.
+func Register(r Registration) {
+	registrations = append(registrations, r)
+}
+
+// Names returns names of all registered providers.
+func Names() []string {
+	var names []string
+	for _, r := range registrations {
+		names = append(names, r.Info.Name)
+	}
+	return names
+}
+
+// Infos returns descriptions of all registered providers.
+func Infos() []Info {
+	var infos []Info
+	for _, r := range registrations {
+		infos = append(infos, r.Info)
+	}
+	return infos
+}
+
+// IsWellKnownHost reports whether given host is served by provider with given
+// name out of the box, without being declared in config.
+func IsWellKnownHost(name string, host string) bool {
+	r, ok := registration(name)
+	if !ok {
+		return false
+	}
+
+	for _, h := range r.Hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// ForHost returns provider serving given host. Aliases declared in config are
+// resolved to real hosts first. Hosts declared in config take precedence over
+// well-known ones.
+func ForHost(host string, conf config.Config) (Provider, error) {
+	alias, aliased := conf.Aliases[host]
+	if aliased {
+		host = alias.Host
+	}
+
+	if h, ok := conf.Hosts[host]; ok {
+		r, ok := registration(h.Provider)
+		if !ok {
+			return nil, ErrUnknownProvider
+		}
+
+		return r.New(Instance{Host: host, APIURL: h.APIURL, WebURL: h.WebURL, Token: h.Token}), nil
+	}
+
+	for _, r := range registrations {
+		for _, h := range r.Hosts {
+			if h == host {
+				return r.New(Instance{Host: host, Token: conf.Token(r.Info.Name, host)}), nil
+			}
+		}
+	}
+
+	if aliased && alias.Provider != "" {
+		r, ok := registration(alias.Provider)
+		if !ok {
+			return nil, ErrUnknownProvider
+		}
+
+		return r.New(Instance{Host: host, Token: conf.Token(alias.Provider, host)}), nil
+	}
+
+	return nil, ErrUnknownHost
+}
+
+// ByName returns provider with given name bound to given host, or to its
+// default host if host is empty. Returns ErrHostRequired if host is empty and
+// the provider has no default host.
+func ByName(name string, host string, conf config.Config) (Provider, error) {
+	r, ok := registration(name)
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	if host == "" {
+		if len(r.Hosts) == 0 {
+			return nil, ErrHostRequired
+		}
+		host = r.Hosts[0]
+	}
+
+	instance := Instance{Host: host, Token: conf.Token(name, host)}
+	if h, ok := conf.Hosts[host]; ok {
+		instance.APIURL = h.APIURL
+		instance.WebURL = h.WebURL
+	}
+
+	return r.New(instance), nil
+}
+
+func registration(name string) (Registration, bool) {
+	for _, r := range registrations {
+		if r.Info.Name == name {
+			return r, true
+		}
+	}
+	return Registration{}, false
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wowu/pro/config"
+)
+
+type fakeProvider struct {
+	Provider
+	instance Instance
+}
+
+func init() {
+	Register(Registration{
+		Info:  Info{Name: "fake"},
+		Hosts: []string{"fake.example.com"},
+		New: func(instance Instance) Provider {
+			return fakeProvider{instance: instance}
+		},
+	})
+}
+
+func TestForHost(t *testing.T) {
+	conf := config.Config{
+		Hosts: map[string]config.Host{
+			"git.corp.example": {Provider: "fake", WebURL: "https://git.corp.example/web", Token: "corp-token"},
+		},
+		Aliases: map[string]config.Alias{
+			"fake-work":   {Host: "fake.example.com"},
+			"corp":        {Host: "git.corp.example"},
+			"mirror":      {Host: "mirror.example.com", Provider: "fake"},
+			"bad-mirror":  {Host: "mirror.example.com"},
+			"fake.mirror": {Host: "mirror.example.com", Provider: "unknown"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		host       string
+		wantHost   string
+		wantWebURL string
+		wantToken  string
+		wantErr    error
+	}{
+		{name: "well-known host", host: "fake.example.com", wantHost: "fake.example.com"},
+		{name: "declared host", host: "git.corp.example", wantHost: "git.corp.example", wantWebURL: "https://git.corp.example/web", wantToken: "corp-token"},
+		{name: "alias of well-known host", host: "fake-work", wantHost: "fake.example.com"},
+		{name: "alias of declared host", host: "corp", wantHost: "git.corp.example", wantWebURL: "https://git.corp.example/web", wantToken: "corp-token"},
+		{name: "alias with provider", host: "mirror", wantHost: "mirror.example.com"},
+		{name: "alias without provider", host: "bad-mirror", wantErr: ErrUnknownHost},
+		{name: "alias with unknown provider", host: "fake.mirror", wantErr: ErrUnknownProvider},
+		{name: "unknown host", host: "unknown.example.com", wantErr: ErrUnknownHost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ForHost(tt.host, conf)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ForHost(%q) error = %v, want %v", tt.host, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ForHost(%q) returned unexpected error: %v", tt.host, err)
+			}
+
+			instance := p.(fakeProvider).instance
+			if instance.Host != tt.wantHost {
+				t.Errorf("ForHost(%q) host = %q, want %q", tt.host, instance.Host, tt.wantHost)
+			}
+			if instance.WebURL != tt.wantWebURL {
+				t.Errorf("ForHost(%q) web URL = %q, want %q", tt.host, instance.WebURL, tt.wantWebURL)
+			}
+			if instance.Token != tt.wantToken {
+				t.Errorf("ForHost(%q) token = %q, want %q", tt.host, instance.Token, tt.wantToken)
+			}
+		})
+	}
+}
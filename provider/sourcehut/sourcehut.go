@@ -0,0 +1,388 @@
+package sourcehut
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/wowu/pro/provider"
+)
+
+// SourceHut has no pull requests, patchsets sent to the project's mailing
+// list on lists.sr.ht are used instead.
+func init() {
+	provider.Register(provider.Registration{
+		Info:  info,
+		Hosts: []string{"git.sr.ht"},
+		New:   New,
+	})
+}
+
+var info = provider.Info{
+	Name:        "sourcehut",
+	Title:       "SourceHut",
+	RequestName: "patchset",
+	RefPrefix:   "#",
+	TokenScopes: "LISTS:RO",
+	TokenHint:   "Add GIT:RO scope to check if branches are pushed.",
+}
+
+// Suffixes of mailing list names tried for a repository, e.g. "~user/repo-devel".
+var listSuffixes = []string{"-devel", "", "-patches"}
+
+var ErrInvalidProjectPath = errors.New("unable to find owner and repository in remote URL")
+var ErrListNotFound = errors.New("mailing list not found")
+
+type SourceHut struct {
+	host     string
+	gitURL   string
+	listsURL string
+	metaURL  string
+	token    string
+}
+
+// New returns SourceHut provider for given instance. Web URL defaults to
+// https://<host>, lists and meta services are expected on sibling hosts, e.g.
+// lists.sr.ht and meta.sr.ht for git.sr.ht.
+func New(instance provider.Instance) provider.Provider {
+	gitURL := strings.TrimSuffix(instance.WebURL, "/")
+	if gitURL == "" {
+		gitURL = "https://" + instance.Host
+	}
+
+	return &SourceHut{
+		host:     instance.Host,
+		gitURL:   gitURL,
+		listsURL: strings.Replace(gitURL, "://git.", "://lists.", 1),
+		metaURL:  strings.Replace(gitURL, "://git.", "://meta.", 1),
+		token:    instance.Token,
+	}
+}
+
+// Splits "~user/repo" into "user" and "repo".
+func parseProjectPath(projectPath string) (owner string, repo string, err error) {
+	owner, repo, ok := strings.Cut(projectPath, "/")
+	if !ok || !strings.HasPrefix(owner, "~") || repo == "" || strings.Contains(repo, "/") {
+		return "", "", ErrInvalidProjectPath
+	}
+
+	return strings.TrimPrefix(owner, "~"), repo, nil
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Runs GraphQL query against given service and unmarshals data of the response into result.
+// https://man.sr.ht/graphql.md
+func graphQL(serviceURL string, token string, query string, variables map[string]interface{}, result interface{}) error {
+	if token == "" {
+		return provider.ErrNoToken
+	}
+
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", serviceURL+"/query", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return provider.ErrUnauthorized
+	case http.StatusOK:
+		var response graphQLResponse
+		err = json.Unmarshal(respBody, &response)
+		if err != nil {
+			return err
+		}
+
+		if len(response.Errors) > 0 {
+			return errors.New(response.Errors[0].Message)
+		}
+
+		return json.Unmarshal(response.Data, result)
+	default:
+		return errors.New("unknown response code: " + fmt.Sprint(resp.StatusCode) + " " + string(respBody))
+	}
+}
+
+func (s *SourceHut) Info() provider.Info {
+	return info
+}
+
+func (s *SourceHut) Host() string {
+	return s.host
+}
+
+func (s *SourceHut) TokenURL() string {
+	return s.metaURL + "/oauth2/personal-token"
+}
+
+func (s *SourceHut) ValidateToken(token string) error {
+	var result struct {
+		Me struct {
+			CanonicalName string `json:"canonicalName"`
+		} `json:"me"`
+	}
+
+	return graphQL(s.listsURL, token, `query { me { canonicalName } }`, nil, &result)
+}
+
+type PatchsetResponse struct {
+	ID      int    `json:"id"`
+	Subject string `json:"subject"`
+	Status  string `json:"status"`
+	Version int    `json:"version"`
+}
+
+func (s *SourceHut) changeRequest(owner string, list string, patchset PatchsetResponse) provider.ChangeRequest {
+	state := provider.StateOpen
+	switch patchset.Status {
+	case "APPLIED":
+		state = provider.StateMerged
+	case "REJECTED", "SUPERSEDED":
+		state = provider.StateClosed
+	}
+
+	return provider.ChangeRequest{
+		Number: patchset.ID,
+		Title:  patchset.Subject,
+		URL:    fmt.Sprintf("%s/~%s/%s/patches/%d", s.listsURL, owner, list, patchset.ID),
+		State:  state,
+	}
+}
+
+// Returns name of the project's mailing list and its recent patchsets, newest first.
+// https://git.sr.ht/~sircmpwn/lists.sr.ht/tree/master/item/api/graph/schema.graphqls
+func (s *SourceHut) recentPatchsets(projectPath string) (owner string, list string, patchsets []PatchsetResponse, err error) {
+	owner, repo, err := parseProjectPath(projectPath)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	query := `query($owner: String!, $list: String!) {
+		user(username: $owner) {
+			list(name: $list) {
+				patches { results { id subject status version } }
+			}
+		}
+	}`
+
+	for _, suffix := range listSuffixes {
+		var result struct {
+			User *struct {
+				List *struct {
+					Patches struct {
+						Results []PatchsetResponse `json:"results"`
+					} `json:"patches"`
+				} `json:"list"`
+			} `json:"user"`
+		}
+
+		err = graphQL(s.listsURL, s.token, query, map[string]interface{}{"owner": owner, "list": repo + suffix}, &result)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		if result.User == nil {
+			return "", "", nil, provider.ErrProjectNotFound
+		}
+
+		if result.User.List != nil {
+			return owner, repo + suffix, result.User.List.Patches.Results, nil
+		}
+	}
+
+	return "", "", nil, ErrListNotFound
+}
+
+// Patchsets are matched by subject of HEAD commit, which is the subject of a
+// single patch sent without cover letter.
+func (s *SourceHut) FindChangeRequestForCommit(projectPath string, commit provider.Commit) (provider.ChangeRequest, error) {
+	owner, list, patchsets, err := s.recentPatchsets(projectPath)
+	if err != nil {
+		if errors.Is(err, ErrListNotFound) {
+			return provider.ChangeRequest{}, provider.ErrNotFound
+		}
+		return provider.ChangeRequest{}, err
+	}
+
+	for _, patchset := range patchsets {
+		if strings.TrimSpace(patchset.Subject) == commit.Subject {
+			return s.changeRequest(owner, list, patchset), nil
+		}
+	}
+
+	return provider.ChangeRequest{}, provider.ErrNotFound
+}
+
+func (s *SourceHut) SubmitInstructions(projectPath string, remote string, branch string) (string, string) {
+	owner, repo, err := parseProjectPath(projectPath)
+	if err != nil {
+		return "Send the commits to the project's mailing list with `git send-email`.", "https://git-send-email.io"
+	}
+
+	instructions := fmt.Sprintf("Send the commit to the project's mailing list with:\n\n"+
+		"  git send-email --to=\"~%s/%s-devel@%s\" -1\n\n"+
+		"Check the project's README for the right mailing list.", owner, repo, strings.TrimPrefix(s.listsURL, "https://"))
+
+	return instructions, "https://git-send-email.io"
+}
+
+// Branches are not associated with patchsets, so patchsets are matched only by commit.
+func (s *SourceHut) FindChangeRequest(projectPath string, branch string) (provider.ChangeRequest, error) {
+	return provider.ChangeRequest{}, provider.ErrNotFound
+}
+
+// Patchset IDs are unique across the instance, so the patchset is looked up
+// directly and its mailing list is taken from the response.
+func (s *SourceHut) GetChangeRequest(projectPath string, number int) (provider.ChangeRequest, error) {
+	query := `query($id: Int!) {
+		patchset(id: $id) {
+			id subject status version
+			list { name owner { canonicalName } }
+		}
+	}`
+
+	var result struct {
+		Patchset *struct {
+			PatchsetResponse
+			List struct {
+				Name  string `json:"name"`
+				Owner struct {
+					CanonicalName string `json:"canonicalName"`
+				} `json:"owner"`
+			} `json:"list"`
+		} `json:"patchset"`
+	}
+
+	err := graphQL(s.listsURL, s.token, query, map[string]interface{}{"id": number}, &result)
+	if err != nil {
+		return provider.ChangeRequest{}, err
+	}
+
+	if result.Patchset == nil {
+		return provider.ChangeRequest{}, provider.ErrNotFound
+	}
+
+	owner := strings.TrimPrefix(result.Patchset.List.Owner.CanonicalName, "~")
+	return s.changeRequest(owner, result.Patchset.List.Name, result.Patchset.PatchsetResponse), nil
+}
+
+func (s *SourceHut) ListOpenChangeRequests(projectPath string) ([]provider.ChangeRequest, error) {
+	owner, list, patchsets, err := s.recentPatchsets(projectPath)
+	if err != nil {
+		if errors.Is(err, ErrListNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Recent patchsets include applied and rejected ones
+	var changeRequests []provider.ChangeRequest
+	for _, patchset := range patchsets {
+		changeRequest := s.changeRequest(owner, list, patchset)
+		if changeRequest.State != provider.StateOpen {
+			continue
+		}
+		changeRequests = append(changeRequests, changeRequest)
+	}
+
+	return changeRequests, nil
+}
+
+// https://git.sr.ht/~sircmpwn/git.sr.ht/tree/master/item/api/graph/schema.graphqls
+func (s *SourceHut) BranchExists(projectPath string, branch string) (bool, error) {
+	owner, repo, err := parseProjectPath(projectPath)
+	if err != nil {
+		return false, err
+	}
+
+	query := `query($owner: String!, $repo: String!, $revspec: String!) {
+		user(username: $owner) {
+			repository(name: $repo) {
+				revparse_single(revspec: $revspec) { id }
+			}
+		}
+	}`
+
+	var result struct {
+		User *struct {
+			Repository *struct {
+				Revparse *struct {
+					ID string `json:"id"`
+				} `json:"revparse_single"`
+			} `json:"repository"`
+		} `json:"user"`
+	}
+
+	err = graphQL(s.gitURL, s.token, query, map[string]interface{}{"owner": owner, "repo": repo, "revspec": "refs/heads/" + branch}, &result)
+	if err != nil {
+		return false, err
+	}
+
+	if result.User == nil || result.User.Repository == nil {
+		return false, provider.ErrProjectNotFound
+	}
+
+	return result.User.Repository.Revparse != nil, nil
+}
+
+func (s *SourceHut) CreateURL(projectPath string, branch string) string {
+	_, url := s.SubmitInstructions(projectPath, "", branch)
+	return url
+}
+
+func (s *SourceHut) FileURL(projectPath string, revision provider.Revision, path string, lines provider.Lines) string {
+	url := fmt.Sprintf("%s/%s/tree/%s/item/%s", s.gitURL, projectPath, provider.EscapePath(revision.Name), provider.EscapePath(path))
+	if lines.Start > 0 {
+		url += fmt.Sprintf("#L%d", lines.Start)
+	}
+	if lines.End > lines.Start {
+		url += fmt.Sprintf("-%d", lines.End)
+	}
+
+	return url
+}
+
+func (s *SourceHut) CommitURL(projectPath string, sha string) string {
+	return fmt.Sprintf("%s/%s/commit/%s", s.gitURL, projectPath, sha)
+}
+
+func (s *SourceHut) TagURL(projectPath string, tag string) string {
+	return fmt.Sprintf("%s/%s/refs/%s", s.gitURL, projectPath, provider.EscapePath(tag))
+}
+
+func (s *SourceHut) HomeURL(projectPath string) string {
+	return s.gitURL + "/" + projectPath
+}
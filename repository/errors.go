@@ -3,7 +3,11 @@ package repository
 import "errors"
 
 var (
-	ErrNoRepository   = errors.New("no git repository found")
-	ErrNoActiveBranch = errors.New("no active branch")
-	ErrNoRemoteOrigin = errors.New("no remote named \"origin\" found")
+	ErrNoRepository     = errors.New("no git repository found")
+	ErrNoActiveBranch   = errors.New("no active branch")
+	ErrRemoteNotFound   = errors.New("remote not found")
+	ErrNoTrackingBranch = errors.New("no remote-tracking branch")
+	ErrNoSuperproject   = errors.New("not a submodule of another repository")
+	ErrOutsideWorktree  = errors.New("path is outside of the working tree")
+	ErrUnknownRevision  = errors.New("unknown revision")
 )
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Return path of given file relative to the root of the working tree, with
+// forward slashes as used in repository URLs. Relative paths are resolved
+// against the current directory. Returns ErrOutsideWorktree if the file is not
+// in the working tree, and error of os.Stat if it does not exist.
+func (repo *Repository) RelativePath(path string) (string, error) {
+	root, err := repo.Root()
+	if err != nil {
+		return "", err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Symlinks are resolved on both sides, e.g. /tmp is /private/tmp on macOS
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+
+	absPath, err = filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return "", err
+	}
+
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return "", err
+	}
+
+	if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideWorktree
+	}
+
+	if relPath == "." {
+		return "", nil
+	}
+
+	return filepath.ToSlash(relPath), nil
+}
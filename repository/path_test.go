@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelativePath(t *testing.T) {
+	repoPath := t.TempDir()
+	initRepository(t, repoPath, "git@github.com:org/repo.git", "main")
+	err := os.Mkdir(filepath.Join(repoPath, "cmd"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repoPath, "cmd", "main.go"), "package main\n")
+
+	repo, err := FindInParents(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(filepath.Join(repoPath, "cmd"))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "main.go", want: "cmd/main.go"},
+		{path: "../cmd/main.go", want: "cmd/main.go"},
+		{path: filepath.Join(repoPath, "cmd", "main.go"), want: "cmd/main.go"},
+		{path: ".", want: "cmd"},
+		{path: "..", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := repo.RelativePath(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RelativePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	_, err = repo.RelativePath("../..")
+	if !errors.Is(err, ErrOutsideWorktree) {
+		t.Errorf("RelativePath(\"../..\") error = %v, want ErrOutsideWorktree", err)
+	}
+
+	_, err = repo.RelativePath("missing.go")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("RelativePath(\"missing.go\") error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// Remote selected to look for change requests.
+type Remote struct {
+	Name string
+
+	// Reason explains why the remote was selected, e.g. "--remote flag".
+	Reason string
+}
+
+// Return remote tracked by given branch (`branch.<name>.remote`), or empty
+// string if there is none.
+func (repo *Repository) BranchRemote(branch string) (string, error) {
+	cfg, err := repo.goGitRepository.Config()
+	if err != nil {
+		return "", err
+	}
+
+	// Branches tracking another local branch have "." as remote
+	remote := cfg.Raw.Section("branch").Subsection(branch).Option("remote")
+	if remote == "." {
+		return "", nil
+	}
+
+	return remote, nil
+}
+
+// Return remote to use for given branch. Remote named explicitly with flag
+// is used first, then the one set with `git config pro.remote <name>`. When
+// neither is set or set to "auto", remote tracked by the branch is preferred,
+// then "upstream", which holds the parent repository of a fork, then "origin".
+// Branch may be empty if it is unknown.
+func (repo *Repository) SelectRemote(flag string, branch string) (Remote, error) {
+	if flag != "" && flag != "auto" {
+		return Remote{Name: flag, Reason: "--remote flag"}, nil
+	}
+
+	cfg, err := repo.goGitRepository.Config()
+	if err != nil {
+		return Remote{}, err
+	}
+
+	if configured := cfg.Raw.Section("pro").Option("remote"); configured != "" && configured != "auto" {
+		return Remote{Name: configured, Reason: "pro.remote config"}, nil
+	}
+
+	if branch != "" {
+		tracked, err := repo.BranchRemote(branch)
+		if err != nil {
+			return Remote{}, err
+		}
+
+		if _, ok := cfg.Remotes[tracked]; ok {
+			return Remote{Name: tracked, Reason: "tracked by " + branch}, nil
+		}
+	}
+
+	if _, ok := cfg.Remotes["upstream"]; ok {
+		return Remote{Name: "upstream", Reason: "fork parent"}, nil
+	}
+
+	return Remote{Name: "origin", Reason: "default"}, nil
+}
+
+// Return remote given branch is pushed to, following the order git uses:
+// `branch.<name>.pushRemote`, `remote.pushDefault`, `branch.<name>.remote`
+// and "origin". Returns empty string if none of them exists.
+func (repo *Repository) PushRemote(branch string) (string, error) {
+	cfg, err := repo.goGitRepository.Config()
+	if err != nil {
+		return "", err
+	}
+
+	candidates := []string{
+		cfg.Raw.Section("branch").Subsection(branch).Option("pushRemote"),
+		cfg.Raw.Section("remote").Option("pushDefault"),
+		cfg.Raw.Section("branch").Subsection(branch).Option("remote"),
+		"origin",
+	}
+
+	for _, name := range candidates {
+		if _, ok := cfg.Remotes[name]; ok {
+			return name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// Return remote and name of the branch given local branch is pushed to. Like
+// with `git push`, it's the branch with the same name in the push remote, so
+// commits are never pushed to the base branch the branch was created from.
+// Tracked branch is used only when push.default is set to "upstream". Returns
+// empty strings if there is no remote to push to.
+func (repo *Repository) PushBranch(branch string) (remote string, remoteBranch string, err error) {
+	cfg, err := repo.goGitRepository.Config()
+	if err != nil {
+		return "", "", err
+	}
+
+	if cfg.Raw.Section("push").Option("default") == "upstream" {
+		remote, err := repo.BranchRemote(branch)
+		if err != nil {
+			return "", "", err
+		}
+
+		tracked, err := repo.TrackedBranch(branch)
+		if err != nil {
+			return "", "", err
+		}
+
+		if remote != "" && tracked != "" {
+			return remote, tracked, nil
+		}
+	}
+
+	remote, err = repo.PushRemote(branch)
+	if err != nil || remote == "" {
+		return "", "", err
+	}
+
+	return remote, branch, nil
+}
+
+// Return name of the remote branch given branch tracks (`branch.<name>.merge`),
+// or empty string if it doesn't track a remote branch.
+func (repo *Repository) TrackedBranch(branch string) (string, error) {
+	cfg, err := repo.goGitRepository.Config()
+	if err != nil {
+		return "", err
+	}
+
+	subsection := cfg.Raw.Section("branch").Subsection(branch)
+	remote := subsection.Option("remote")
+	if remote == "" || remote == "." {
+		return "", nil
+	}
+
+	return strings.TrimPrefix(subsection.Option("merge"), "refs/heads/"), nil
+}
+
+// Return default branch of given remote, as recorded in
+// refs/remotes/<remote>/HEAD by clone or `git remote set-head`. Returns empty
+// string if it's unknown.
+func (repo *Repository) DefaultBranch(remote string) (string, error) {
+	ref, err := repo.goGitRepository.Storer.Reference(plumbing.NewRemoteHEADReferenceName(remote))
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if ref.Type() != plumbing.SymbolicReference {
+		return "", nil
+	}
+
+	return strings.TrimPrefix(ref.Target().String(), "refs/remotes/"+remote+"/"), nil
+}
+
+// Branch named by user, which may be a local branch, a remote-tracking branch
+// like "origin/feature", or a branch existing only in the remote.
+type Branch struct {
+	// Local is the name of the local branch, empty if there is none.
+	Local string
+
+	// Remote is the remote of a remote-tracking branch, e.g. "origin".
+	Remote string
+
+	// Name is the name of the branch in the remote.
+	Name string
+
+	// Hash of the commit the branch points to, empty if the branch is not
+	// known locally.
+	Hash string
+}
+
+// Resolve branch name given by user. Local branches take precedence over
+// remote-tracking branches, names matching neither are assumed to be
+// branches existing only in the remote. Remote name of a local branch is the
+// one it's pushed to, see PushBranch, not the base branch it tracks, e.g.
+// "main" for a branch created with `git checkout -b feature origin/main`.
+func (repo *Repository) ResolveBranch(name string) (Branch, error) {
+	local, err := repo.goGitRepository.Reference(plumbing.NewBranchReferenceName(name), true)
+	if err == nil {
+		_, remoteBranch, err := repo.PushBranch(name)
+		if err != nil {
+			return Branch{}, err
+		}
+		if remoteBranch == "" {
+			remoteBranch = name
+		}
+
+		return Branch{Local: name, Name: remoteBranch, Hash: local.Hash().String()}, nil
+	}
+	if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return Branch{}, err
+	}
+
+	cfg, err := repo.goGitRepository.Config()
+	if err != nil {
+		return Branch{}, err
+	}
+
+	for remote := range cfg.Remotes {
+		remoteBranch, ok := strings.CutPrefix(name, remote+"/")
+		if !ok {
+			continue
+		}
+
+		ref, err := repo.goGitRepository.Reference(plumbing.NewRemoteReferenceName(remote, remoteBranch), true)
+		if err == nil {
+			return Branch{Remote: remote, Name: remoteBranch, Hash: ref.Hash().String()}, nil
+		}
+	}
+
+	return Branch{Name: name}, nil
+}
+
+// Return names of local branches and remote-tracking branches, e.g.
+// "feature" and "origin/feature".
+func (repo *Repository) BranchNames() ([]string, error) {
+	refs, err := repo.goGitRepository.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsBranch() || (ref.Name().IsRemote() && ref.Type() == plumbing.HashReference) {
+			names = append(names, ref.Name().Short())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
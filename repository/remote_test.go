@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestSelectRemote(t *testing.T) {
+	const remotes = "[remote \"origin\"]\n\turl = git@github.com:me/repo.git\n" +
+		"[remote \"upstream\"]\n\turl = git@github.com:org/repo.git\n"
+
+	tests := []struct {
+		name   string
+		config string
+		flag   string
+		branch string
+		want   string
+	}{
+		{
+			name:   "flag",
+			config: remotes + "[pro]\n\tremote = upstream\n",
+			flag:   "fork",
+			want:   "fork",
+		},
+		{
+			name:   "pro.remote config",
+			config: remotes + "[pro]\n\tremote = origin\n[branch \"feature\"]\n\tremote = upstream\n",
+			flag:   "auto",
+			branch: "feature",
+			want:   "origin",
+		},
+		{
+			name:   "branch remote",
+			config: remotes + "[remote \"fork\"]\n\turl = git@github.com:other/repo.git\n[branch \"feature\"]\n\tremote = fork\n",
+			flag:   "auto",
+			branch: "feature",
+			want:   "fork",
+		},
+		{
+			name:   "missing branch remote",
+			config: remotes + "[branch \"feature\"]\n\tremote = gone\n",
+			branch: "feature",
+			want:   "upstream",
+		},
+		{
+			name:   "local branch remote",
+			config: remotes + "[branch \"feature\"]\n\tremote = .\n",
+			branch: "feature",
+			want:   "upstream",
+		},
+		{
+			name:   "upstream",
+			config: remotes,
+			branch: "feature",
+			want:   "upstream",
+		},
+		{
+			name:   "origin",
+			config: "[remote \"origin\"]\n\turl = git@github.com:me/repo.git\n",
+			want:   "origin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			_, err := git.PlainInit(repoPath, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, filepath.Join(repoPath, ".git", "config"), tt.config)
+
+			repo, err := FindInParents(repoPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repo.SelectRemote(tt.flag, tt.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("SelectRemote(%q, %q) = %q, want %q", tt.flag, tt.branch, got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackedBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "remote branch",
+			config: "[branch \"feature-x\"]\n\tremote = origin\n\tmerge = refs/heads/jdoe/feature-x\n",
+			want:   "jdoe/feature-x",
+		},
+		{
+			name:   "local branch",
+			config: "[branch \"feature-x\"]\n\tremote = .\n\tmerge = refs/heads/main\n",
+			want:   "",
+		},
+		{
+			name:   "no upstream",
+			config: "",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			_, err := git.PlainInit(repoPath, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, filepath.Join(repoPath, ".git", "config"), tt.config)
+
+			repo, err := FindInParents(repoPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repo.TrackedBranch("feature-x")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("TrackedBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	repoPath := t.TempDir()
+	goGitRepo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repoPath, ".git", "config"), "[remote \"origin\"]\n\turl = git@github.com:org/repo.git\n")
+
+	repo, err := FindInParents(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.DefaultBranch("origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("DefaultBranch() without origin/HEAD = %q, want empty", got)
+	}
+
+	err = goGitRepo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.NewRemoteHEADReferenceName("origin"), plumbing.NewRemoteReferenceName("origin", "release/2.x")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = repo.DefaultBranch("origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "release/2.x" {
+		t.Errorf("DefaultBranch() = %q, want %q", got, "release/2.x")
+	}
+}
+
+func TestResolveBranch(t *testing.T) {
+	repoPath := t.TempDir()
+	goGitRepo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(repoPath, ".git", "config")
+	branchConfig := "[remote \"origin\"]\n\turl = git@github.com:org/repo.git\n" +
+		"[branch \"feature\"]\n\tremote = origin\n\tmerge = refs/heads/jdoe/feature\n" +
+		"[branch \"topic\"]\n\tremote = origin\n\tmerge = refs/heads/main\n"
+	writeFile(t, configPath, branchConfig)
+
+	worktree, err := goGitRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	hash, err := worktree.Commit("commit", &git.CommitOptions{Author: signature, AllowEmptyCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName("feature"),
+		plumbing.NewBranchReferenceName("local"),
+		plumbing.NewBranchReferenceName("topic"),
+		plumbing.NewRemoteReferenceName("origin", "remote-only"),
+	} {
+		err := goGitRepo.Storer.SetReference(plumbing.NewHashReference(name, hash))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err := FindInParents(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		pushUpstream bool
+		want         Branch
+	}{
+		{name: "feature", want: Branch{Local: "feature", Name: "feature", Hash: hash.String()}},
+		{name: "feature", pushUpstream: true, want: Branch{Local: "feature", Name: "jdoe/feature", Hash: hash.String()}},
+		{name: "topic", want: Branch{Local: "topic", Name: "topic", Hash: hash.String()}},
+		{name: "local", want: Branch{Local: "local", Name: "local", Hash: hash.String()}},
+		{name: "local", pushUpstream: true, want: Branch{Local: "local", Name: "local", Hash: hash.String()}},
+		{name: "origin/remote-only", want: Branch{Remote: "origin", Name: "remote-only", Hash: hash.String()}},
+		{name: "missing", want: Branch{Name: "missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.pushUpstream {
+				writeFile(t, configPath, branchConfig+"[push]\n\tdefault = upstream\n")
+			} else {
+				writeFile(t, configPath, branchConfig)
+			}
+
+			got, err := repo.ResolveBranch(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveBranch(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	names, err := repo.BranchNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"feature", "local", "master", "origin/remote-only", "topic"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("BranchNames() = %v, want %v", names, want)
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/go-git/go-git/v6"
 	"github.com/go-git/go-git/v6/plumbing"
@@ -11,7 +12,10 @@ type Repository struct {
 	goGitRepository *git.Repository
 }
 
-// Return git repository in given directory or parent directories.
+// Return git repository in given directory or parent directories. Linked
+// worktrees and submodules have a ".git" file pointing to their git directory
+// instead. Linked worktrees have their own HEAD, but share config and refs
+// with the main working tree.
 func FindInParents(path string) (Repository, error) {
 	goGitRepository, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
 	if err != nil {
@@ -40,20 +44,106 @@ func (repo *Repository) CurrentBranchName() (string, error) {
 	return head.Target().Short(), nil
 }
 
-func (repo *Repository) OriginUrl() (string, error) {
-	origin, err := repo.goGitRepository.Remote("origin")
+// Return URL of given remote with insteadOf rewrites from git config applied.
+// URL rewritten only by pushInsteadOf is used when no insteadOf rule matches,
+// as shorthands are sometimes configured only for pushing.
+func (repo *Repository) RemoteURL(name string) (string, error) {
+	cfg, err := repo.goGitRepository.Config()
 	if err != nil {
-		if errors.Is(err, git.ErrRemoteNotFound) {
-			return "", ErrNoRemoteOrigin
-		}
-
 		return "", err
 	}
 
-	urls := origin.Config().URLs
+	// Raw config is used, as go-git applies only rewrites of repository config
+	remotes := cfg.Raw.Section("remote")
+	if !remotes.HasSubsection(name) {
+		return "", ErrRemoteNotFound
+	}
+
+	remote := remotes.Subsection(name)
+	urls := remote.OptionAll("url")
 	if len(urls) == 0 {
-		return "", ErrNoRemoteOrigin
+		return "", ErrRemoteNotFound
+	}
+
+	rewrites, err := loadURLRewrites(cfg.Raw)
+	if err != nil {
+		return "", err
+	}
+
+	if url, ok := rewriteURL(urls[0], rewrites.fetch); ok {
+		return url, nil
+	}
+
+	// Like git, pushInsteadOf is ignored when push URL is set explicitly
+	if !remote.HasOption("pushurl") {
+		if url, ok := rewriteURL(urls[0], rewrites.push); ok {
+			return url, nil
+		}
 	}
 
 	return urls[0], nil
 }
+
+// Return message of the commit with given hash.
+func (repo *Repository) CommitMessage(hash string) (string, error) {
+	commit, err := repo.goGitRepository.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return "", err
+	}
+
+	return commit.Message, nil
+}
+
+// Return first line of message of the commit with given hash.
+func (repo *Repository) CommitSubject(hash string) (string, error) {
+	message, err := repo.CommitMessage(hash)
+	if err != nil {
+		return "", err
+	}
+
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(subject), nil
+}
+
+// Return value of given trailer (e.g. "Change-Id") in message of the commit
+// with given hash, or empty string if there is none.
+func (repo *Repository) CommitTrailer(hash string, key string) (string, error) {
+	message, err := repo.CommitMessage(hash)
+	if err != nil {
+		return "", err
+	}
+
+	return trailer(message, key), nil
+}
+
+// Return value of given trailer in commit message. Trailers are "Key: value"
+// lines in the last paragraph of the message other than the subject, the last
+// occurrence wins.
+func trailer(message string, key string) string {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		return ""
+	}
+
+	lastParagraph := paragraphs[len(paragraphs)-1]
+
+	value := ""
+	for _, line := range strings.Split(lastParagraph, "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			value = strings.TrimSpace(v)
+		}
+	}
+
+	return value
+}
+
+// Return hash of the commit HEAD points to.
+func (repo *Repository) HeadHash() (string, error) {
+	head, err := repo.goGitRepository.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.Hash().String(), nil
+}
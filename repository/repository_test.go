@@ -0,0 +1,45 @@
+package repository
+
+import "testing"
+
+func TestTrailer(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "single trailer",
+			message: "Fix bug\n\nLonger description.\n\nChange-Id: I0123abcd\n",
+			want:    "I0123abcd",
+		},
+		{
+			name:    "among other trailers",
+			message: "Fix bug\n\nSigned-off-by: A <a@example.com>\nChange-Id: I0123abcd\nReviewed-by: B <b@example.com>",
+			want:    "I0123abcd",
+		},
+		{
+			name:    "case insensitive key",
+			message: "Fix bug\n\nchange-id: I0123abcd",
+			want:    "I0123abcd",
+		},
+		{
+			name:    "not in last paragraph",
+			message: "Fix bug\n\nChange-Id: I0123abcd\n\nSigned-off-by: A <a@example.com>",
+			want:    "",
+		},
+		{
+			name:    "subject only",
+			message: "Change-Id: in subject is not a trailer\n",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trailer(tt.message, "Change-Id"); got != tt.want {
+				t.Errorf("trailer(%q) = %q, want %q", tt.message, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// Return SHA of the commit given revision points to, e.g. "HEAD~2", "v1.0",
+// "main" or abbreviated SHA. Returns ErrUnknownRevision if there is no such
+// revision.
+func (repo *Repository) ResolveCommit(rev string) (string, error) {
+	hash, err := repo.goGitRepository.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) || errors.Is(err, plumbing.ErrObjectNotFound) {
+			return "", ErrUnknownRevision
+		}
+		return "", err
+	}
+
+	return hash.String(), nil
+}
+
+// Return SHA of the commit given tag points to, for both lightweight and
+// annotated tags. Returns ErrUnknownRevision if there is no such tag.
+func (repo *Repository) ResolveTag(name string) (string, error) {
+	ref, err := repo.goGitRepository.Tag(name)
+	if err != nil {
+		if errors.Is(err, git.ErrTagNotFound) {
+			return "", ErrUnknownRevision
+		}
+		return "", err
+	}
+
+	tag, err := repo.goGitRepository.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		// Lightweight tag points directly to the commit
+		return ref.Hash().String(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := tag.Commit()
+	if err != nil {
+		return "", err
+	}
+
+	return commit.Hash.String(), nil
+}
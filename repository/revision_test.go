@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestResolveRevision(t *testing.T) {
+	repoPath := t.TempDir()
+	goGitRepo, first := initRepository(t, repoPath, "git@github.com:org/repo.git", "main")
+
+	worktree, err := goGitRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}
+	second, err := worktree.Commit("second", &git.CommitOptions{Author: signature, Parents: []plumbing.Hash{first}, AllowEmptyCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = goGitRepo.CreateTag("v1.0", first, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = goGitRepo.CreateTag("v2.0", second, &git.CreateTagOptions{Tagger: signature, Message: "v2.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := FindInParents(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commits := []struct {
+		rev  string
+		want plumbing.Hash
+	}{
+		{rev: "HEAD", want: second},
+		{rev: "HEAD~1", want: first},
+		{rev: second.String()[:7], want: second},
+		{rev: "v1.0", want: first},
+		{rev: "v2.0", want: second},
+	}
+
+	for _, tt := range commits {
+		t.Run(tt.rev, func(t *testing.T) {
+			got, err := repo.ResolveCommit(tt.rev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want.String() {
+				t.Errorf("ResolveCommit(%q) = %s, want %s", tt.rev, got, tt.want)
+			}
+		})
+	}
+
+	tags := []struct {
+		name string
+		want plumbing.Hash
+	}{
+		{name: "v1.0", want: first},
+		{name: "v2.0", want: second},
+	}
+
+	for _, tt := range tags {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.ResolveTag(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want.String() {
+				t.Errorf("ResolveTag(%q) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := repo.ResolveCommit("missing"); !errors.Is(err, ErrUnknownRevision) {
+		t.Errorf("ResolveCommit(\"missing\") error = %v, want ErrUnknownRevision", err)
+	}
+	if _, err := repo.ResolveTag("main"); !errors.Is(err, ErrUnknownRevision) {
+		t.Errorf("ResolveTag(\"main\") error = %v, want ErrUnknownRevision", err)
+	}
+}
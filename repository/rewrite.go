@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	format "github.com/go-git/go-git/v6/plumbing/format/config"
+)
+
+// URL rewrite rule set by `url.<base>.insteadOf` or `url.<base>.pushInsteadOf`,
+// remote URLs starting with prefix have it replaced by base.
+type urlRewrite struct {
+	base   string
+	prefix string
+}
+
+type urlRewrites struct {
+	fetch []urlRewrite
+	push  []urlRewrite
+}
+
+// Add rewrite rules declared in given config. Rules have to be added in the
+// order git reads config files: system, global and repository config.
+func (r *urlRewrites) add(cfg *format.Config) {
+	for _, subsection := range cfg.Section("url").Subsections {
+		for _, prefix := range subsection.OptionAll("insteadOf") {
+			r.fetch = append(r.fetch, urlRewrite{base: subsection.Name, prefix: prefix})
+		}
+		for _, prefix := range subsection.OptionAll("pushInsteadOf") {
+			r.push = append(r.push, urlRewrite{base: subsection.Name, prefix: prefix})
+		}
+	}
+}
+
+// Rewrite URL using the rule with the longest matching prefix. When prefixes
+// are equally long, the rule read first wins, same as in git.
+func rewriteURL(url string, rules []urlRewrite) (string, bool) {
+	var match *urlRewrite
+	for i, rule := range rules {
+		if strings.HasPrefix(url, rule.prefix) && (match == nil || len(rule.prefix) > len(match.prefix)) {
+			match = &rules[i]
+		}
+	}
+
+	if match == nil {
+		return url, false
+	}
+
+	return match.base + strings.TrimPrefix(url, match.prefix), true
+}
+
+// Return paths of system and global git config files, in the order git reads
+// them. Missing files are skipped when reading.
+// https://git-scm.com/docs/git-config#FILES
+func userConfigPaths() []string {
+	var paths []string
+
+	if !gitBool(os.Getenv("GIT_CONFIG_NOSYSTEM")) {
+		if system := os.Getenv("GIT_CONFIG_SYSTEM"); system != "" {
+			paths = append(paths, system)
+		} else {
+			paths = append(paths, "/etc/gitconfig")
+		}
+	}
+
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		return append(paths, global)
+	}
+
+	home, err := os.UserHomeDir()
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	} else if err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "git", "config"))
+	}
+
+	if err == nil {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+
+	return paths
+}
+
+// Parse boolean value like git does, e.g. "yes", "off" or "1". Empty and
+// invalid values are false.
+// https://git-scm.com/docs/git-config#Documentation/git-config.txt-boolean
+func gitBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on":
+		return true
+	case "", "false", "no", "off":
+		return false
+	}
+
+	number, err := strconv.Atoi(value)
+	return err == nil && number != 0
+}
+
+// Read git config file, returns nil if it doesn't exist.
+func readConfigFile(path string) (*format.Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	cfg := format.New()
+	err = format.NewDecoder(file).Decode(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Return URL rewrite rules from system, global and given repository config.
+func loadURLRewrites(repoConfig *format.Config) (urlRewrites, error) {
+	var rewrites urlRewrites
+
+	for _, path := range userConfigPaths() {
+		cfg, err := readConfigFile(path)
+		if err != nil {
+			return urlRewrites{}, err
+		}
+		if cfg != nil {
+			rewrites.add(cfg)
+		}
+	}
+
+	rewrites.add(repoConfig)
+
+	return rewrites, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v6"
+)
+
+func TestRewriteURL(t *testing.T) {
+	rules := []urlRewrite{
+		{base: "git@github.com:", prefix: "gh:"},
+		{base: "git@github.com:work/", prefix: "gh:work/"},
+		{base: "https://first.example.com/", prefix: "ex:"},
+		{base: "https://second.example.com/", prefix: "ex:"},
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+		ok   bool
+	}{
+		{name: "matching prefix", url: "gh:org/repo", want: "git@github.com:org/repo", ok: true},
+		{name: "longest prefix wins", url: "gh:work/repo", want: "git@github.com:work/repo", ok: true},
+		{name: "first rule wins on tie", url: "ex:repo", want: "https://first.example.com/repo", ok: true},
+		{name: "no match", url: "git@gitlab.com:org/repo", want: "git@gitlab.com:org/repo", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rewriteURL(tt.url, rules)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("rewriteURL(%q) = %q, %v, want %q, %v", tt.url, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteURLRewrites(t *testing.T) {
+	tests := []struct {
+		name     string
+		system   string
+		global   string
+		local    string
+		noSystem string
+		want     string
+	}{
+		{
+			name:  "no rewrites",
+			local: "[remote \"origin\"]\n\turl = git@github.com:org/repo.git\n",
+			want:  "git@github.com:org/repo.git",
+		},
+		{
+			name:   "global insteadOf",
+			global: "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n",
+			local:  "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			want:   "git@github.com:org/repo.git",
+		},
+		{
+			name:   "system insteadOf",
+			system: "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n",
+			local:  "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			want:   "git@github.com:org/repo.git",
+		},
+		{
+			name:     "system config skipped",
+			system:   "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n",
+			local:    "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			noSystem: "true",
+			want:     "gh:org/repo.git",
+		},
+		{
+			name:     "system config skipped with number",
+			system:   "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n",
+			local:    "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			noSystem: "1",
+			want:     "gh:org/repo.git",
+		},
+		{
+			name:     "system config not skipped with false",
+			system:   "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n",
+			local:    "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			noSystem: "false",
+			want:     "git@github.com:org/repo.git",
+		},
+		{
+			name:     "system config not skipped with zero",
+			system:   "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n",
+			local:    "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			noSystem: "0",
+			want:     "git@github.com:org/repo.git",
+		},
+		{
+			name:   "longest prefix wins over later config",
+			global: "[url \"git@github.com:work/\"]\n\tinsteadOf = gh:work/\n",
+			local:  "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n[remote \"origin\"]\n\turl = gh:work/repo.git\n",
+			want:   "git@github.com:work/repo.git",
+		},
+		{
+			name:   "earlier config wins on same prefix",
+			system: "[url \"git@github.com:\"]\n\tinsteadOf = gh:\n",
+			global: "[url \"git@gitlab.com:\"]\n\tinsteadOf = gh:\n",
+			local:  "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			want:   "git@github.com:org/repo.git",
+		},
+		{
+			name:   "pushInsteadOf without insteadOf",
+			global: "[url \"git@github.com:\"]\n\tpushInsteadOf = gh:\n",
+			local:  "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			want:   "git@github.com:org/repo.git",
+		},
+		{
+			name:   "insteadOf preferred over pushInsteadOf",
+			global: "[url \"https://github.com/\"]\n\tinsteadOf = gh:\n[url \"git@github.com:\"]\n\tpushInsteadOf = gh:\n",
+			local:  "[remote \"origin\"]\n\turl = gh:org/repo.git\n",
+			want:   "https://github.com/org/repo.git",
+		},
+		{
+			name:   "pushInsteadOf ignored with push URL",
+			global: "[url \"git@github.com:\"]\n\tpushInsteadOf = gh:\n",
+			local:  "[remote \"origin\"]\n\turl = gh:org/repo.git\n\tpushurl = git@gitlab.com:org/repo.git\n",
+			want:   "gh:org/repo.git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			systemPath := filepath.Join(dir, "system")
+			globalPath := filepath.Join(dir, "global")
+			writeFile(t, systemPath, tt.system)
+			writeFile(t, globalPath, tt.global)
+			t.Setenv("GIT_CONFIG_SYSTEM", systemPath)
+			t.Setenv("GIT_CONFIG_GLOBAL", globalPath)
+			t.Setenv("GIT_CONFIG_NOSYSTEM", tt.noSystem)
+
+			repoPath := filepath.Join(dir, "repo")
+			_, err := git.PlainInit(repoPath, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, filepath.Join(repoPath, ".git", "config"), tt.local)
+
+			repo, err := FindInParents(repoPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repo.RemoteURL("origin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RemoteURL(origin) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v6/plumbing/filemode"
+)
+
+// Return root directory of the working tree, e.g. the directory of a linked
+// worktree or a submodule rather than of the repository owning its objects.
+func (repo *Repository) Root() (string, error) {
+	worktree, err := repo.goGitRepository.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	return worktree.Filesystem().Root(), nil
+}
+
+// Return repository the repository is a submodule of. Like git, the parent
+// repository is found in parent directories of the working tree and has to
+// record the working tree as a submodule in its index. Returns
+// ErrNoSuperproject if the repository is not a submodule.
+func (repo *Repository) Superproject() (Repository, error) {
+	root, err := repo.Root()
+	if err != nil {
+		return Repository{}, err
+	}
+
+	parent, err := FindInParents(filepath.Dir(root))
+	if err != nil {
+		if errors.Is(err, ErrNoRepository) {
+			return Repository{}, ErrNoSuperproject
+		}
+		return Repository{}, err
+	}
+
+	parentRoot, err := parent.Root()
+	if err != nil {
+		return Repository{}, err
+	}
+
+	path, err := filepath.Rel(parentRoot, root)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	index, err := parent.goGitRepository.Storer.Index()
+	if err != nil {
+		return Repository{}, err
+	}
+
+	for _, entry := range index.Entries {
+		if entry.Mode == filemode.Submodule && entry.Name == filepath.ToSlash(path) {
+			return parent, nil
+		}
+	}
+
+	return Repository{}, ErrNoSuperproject
+}
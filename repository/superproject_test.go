@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/filemode"
+	"github.com/go-git/go-git/v6/plumbing/format/index"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// Create repository with origin remote and single commit on given branch.
+func initRepository(t *testing.T, path string, originURL string, branch string) (*git.Repository, plumbing.Hash) {
+	t.Helper()
+
+	goGitRepo, err := git.PlainInit(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(path, ".git", "config"), "[remote \"origin\"]\n\turl = "+originURL+"\n")
+
+	worktree, err := goGitRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}
+	hash, err := worktree.Commit("init", &git.CommitOptions{Author: signature, AllowEmptyCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = goGitRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = goGitRepo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return goGitRepo, hash
+}
+
+func mkdir(t *testing.T, path string) {
+	t.Helper()
+
+	err := os.MkdirAll(path, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Build layout of `git worktree add` and `git submodule add`:
+//
+//	main/                 repository on branch "main"
+//	main/sub/             submodule on branch "subfeature"
+//	main/nested/          repository not registered as submodule
+//	linked/               linked worktree of main on branch "feature"
+func buildLayout(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main")
+	mainRepo, mainHead := initRepository(t, mainPath, "git@github.com:org/main.git", "main")
+
+	// Linked worktree
+	linkedPath := filepath.Join(dir, "linked")
+	worktreeGitDir := filepath.Join(mainPath, ".git", "worktrees", "linked")
+	mkdir(t, worktreeGitDir)
+	mkdir(t, filepath.Join(linkedPath, "deep"))
+	err := mainRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), mainHead))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(worktreeGitDir, "HEAD"), "ref: refs/heads/feature\n")
+	writeFile(t, filepath.Join(worktreeGitDir, "commondir"), "../..\n")
+	writeFile(t, filepath.Join(worktreeGitDir, "gitdir"), filepath.Join(linkedPath, ".git")+"\n")
+	writeFile(t, filepath.Join(linkedPath, ".git"), "gitdir: "+worktreeGitDir+"\n")
+
+	// Submodule with git directory absorbed into the parent
+	subPath := filepath.Join(mainPath, "sub")
+	_, subHead := initRepository(t, subPath, "git@gitlab.com:org/sub.git", "subfeature")
+	mkdir(t, filepath.Join(mainPath, ".git", "modules"))
+	err = os.Rename(filepath.Join(subPath, ".git"), filepath.Join(mainPath, ".git", "modules", "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(subPath, ".git"), "gitdir: ../.git/modules/sub\n")
+	mkdir(t, filepath.Join(subPath, "deep"))
+
+	idx, err := mainRepo.Storer.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Entries = append(idx.Entries, &index.Entry{Name: "sub", Mode: filemode.Submodule, Hash: subHead})
+	err = mainRepo.Storer.SetIndex(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nested repository which is not a submodule
+	initRepository(t, filepath.Join(mainPath, "nested"), "git@github.com:org/nested.git", "main")
+
+	return dir
+}
+
+func TestFindInParentsLayouts(t *testing.T) {
+	dir := buildLayout(t)
+
+	tests := []struct {
+		name      string
+		path      string
+		root      string
+		branch    string
+		originURL string
+	}{
+		{name: "main", path: "main", root: "main", branch: "main", originURL: "git@github.com:org/main.git"},
+		{name: "linked worktree", path: "linked", root: "linked", branch: "feature", originURL: "git@github.com:org/main.git"},
+		{name: "linked worktree subdirectory", path: "linked/deep", root: "linked", branch: "feature", originURL: "git@github.com:org/main.git"},
+		{name: "submodule", path: "main/sub", root: "main/sub", branch: "subfeature", originURL: "git@gitlab.com:org/sub.git"},
+		{name: "submodule subdirectory", path: "main/sub/deep", root: "main/sub", branch: "subfeature", originURL: "git@gitlab.com:org/sub.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := FindInParents(filepath.Join(dir, tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root, err := repo.Root()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root != filepath.Join(dir, tt.root) {
+				t.Errorf("Root() = %q, want %q", root, filepath.Join(dir, tt.root))
+			}
+
+			branch, err := repo.CurrentBranchName()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if branch != tt.branch {
+				t.Errorf("CurrentBranchName() = %q, want %q", branch, tt.branch)
+			}
+
+			originURL, err := repo.RemoteURL("origin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if originURL != tt.originURL {
+				t.Errorf("RemoteURL(origin) = %q, want %q", originURL, tt.originURL)
+			}
+		})
+	}
+}
+
+func TestSuperproject(t *testing.T) {
+	dir := buildLayout(t)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+		err  error
+	}{
+		{name: "submodule", path: "main/sub/deep", want: "main"},
+		{name: "top-level repository", path: "main", err: ErrNoSuperproject},
+		{name: "nested repository", path: "main/nested", err: ErrNoSuperproject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := FindInParents(filepath.Join(dir, tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			superproject, err := repo.Superproject()
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Superproject() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root, err := superproject.Root()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root != filepath.Join(dir, tt.want) {
+				t.Errorf("Superproject().Root() = %q, want %q", root, filepath.Join(dir, tt.want))
+			}
+		})
+	}
+}
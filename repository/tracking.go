@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// Tracking describes how a local branch relates to its remote-tracking branch.
+type Tracking struct {
+	// Remote is the name of the remote, e.g. "origin".
+	Remote string
+
+	// Branch is the name of the branch in the remote.
+	Branch string
+
+	// Ahead is the number of local commits missing in the remote branch.
+	Ahead int
+
+	// Behind is the number of remote commits missing in the local branch.
+	Behind int
+}
+
+// Return tracking status of given local branch. Remote-tracking branch is the
+// one the branch is pushed to, see PushBranch. Upstream set to another branch,
+// e.g. "origin/main" the branch was created from, is not compared with, as
+// local commits are not pushed there. Returns ErrNoTrackingBranch if the
+// branch was never pushed.
+func (repo *Repository) Tracking(branch string) (Tracking, error) {
+	remote, remoteBranch, err := repo.PushBranch(branch)
+	if err != nil {
+		return Tracking{}, err
+	}
+
+	if remote == "" {
+		return Tracking{}, ErrNoTrackingBranch
+	}
+
+	local, err := repo.goGitRepository.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return Tracking{}, err
+	}
+
+	tracking, err := repo.goGitRepository.Reference(plumbing.NewRemoteReferenceName(remote, remoteBranch), true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return Tracking{}, ErrNoTrackingBranch
+		}
+		return Tracking{}, err
+	}
+
+	ahead, behind, err := repo.aheadBehind(local.Hash(), tracking.Hash())
+	if err != nil {
+		return Tracking{}, err
+	}
+
+	return Tracking{Remote: remote, Branch: remoteBranch, Ahead: ahead, Behind: behind}, nil
+}
+
+// Count commits reachable only from local and only from remote commit with
+// `git rev-list --left-right --count`, which stops walking history where both
+// sides meet instead of reading all of it.
+func (repo *Repository) aheadBehind(local plumbing.Hash, remote plumbing.Hash) (ahead int, behind int, err error) {
+	if local == remote {
+		return 0, 0, nil
+	}
+
+	root, err := repo.Root()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	cmd := exec.Command("git", "rev-list", "--left-right", "--count", local.String()+"..."+remote.String())
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = fmt.Sscan(string(output), &ahead, &behind)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected git rev-list output %q: %w", output, err)
+	}
+
+	return ahead, behind, nil
+}
+
+// Push local branch to given branch of the remote with git, so user's
+// credentials and SSH config are used. With setUpstream, the remote branch
+// becomes upstream of the local one, like with `git push -u`.
+func (repo *Repository) Push(remote string, branch string, remoteBranch string, setUpstream bool) error {
+	worktree, err := repo.goGitRepository.Worktree()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"push"}
+	if setUpstream {
+		args = append(args, "--set-upstream")
+	}
+	args = append(args, remote, "refs/heads/"+branch+":refs/heads/"+remoteBranch)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = worktree.Filesystem().Root()
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestTracking(t *testing.T) {
+	repoPath := t.TempDir()
+	goGitRepo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repoPath, ".git", "config"),
+		"[remote \"origin\"]\n\turl = git@github.com:org/repo.git\n"+
+			"[branch \"feature\"]\n\tremote = origin\n\tmerge = refs/heads/feature\n")
+
+	worktree, err := goGitRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(minute int, parents ...plumbing.Hash) plumbing.Hash {
+		t.Helper()
+
+		signature := &object.Signature{Name: "Test", Email: "test@example.com", When: start.Add(time.Duration(minute) * time.Minute)}
+		hash, err := worktree.Commit("commit", &git.CommitOptions{
+			Author:            signature,
+			Committer:         signature,
+			Parents:           parents,
+			AllowEmptyCommits: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	setRef := func(name plumbing.ReferenceName, hash plumbing.Hash) {
+		t.Helper()
+
+		err := goGitRepo.Storer.SetReference(plumbing.NewHashReference(name, hash))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c1 := commit(1)
+	c2 := commit(2, c1)
+	c3 := commit(3, c2)
+	c4 := commit(4, c3)
+	r1 := commit(5, c2)
+	merge := commit(6, c4, r1)
+
+	// Commits with skewed clock are older than their parents
+	s1 := commit(-60, c4)
+	s2 := commit(-50, s1)
+	r2 := commit(7, s1)
+
+	repo, err := FindInParents(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setRef(plumbing.NewBranchReferenceName("feature"), c4)
+	_, err = repo.Tracking("feature")
+	if !errors.Is(err, ErrNoTrackingBranch) {
+		t.Fatalf("Tracking() error = %v, want ErrNoTrackingBranch", err)
+	}
+
+	tests := []struct {
+		name   string
+		local  plumbing.Hash
+		remote plumbing.Hash
+		ahead  int
+		behind int
+	}{
+		{name: "up to date", local: c4, remote: c4},
+		{name: "ahead", local: c4, remote: c2, ahead: 2},
+		{name: "behind", local: c2, remote: c4, behind: 2},
+		{name: "diverged", local: c4, remote: r1, ahead: 2, behind: 1},
+		{name: "merged remote", local: merge, remote: r1, ahead: 3},
+		{name: "merged local", local: r1, remote: merge, behind: 3},
+		{name: "clock skew ahead", local: s2, remote: c4, ahead: 2},
+		{name: "clock skew diverged", local: s2, remote: r2, ahead: 1, behind: 1},
+		{name: "clock skew behind", local: c2, remote: s2, behind: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRef(plumbing.NewBranchReferenceName("feature"), tt.local)
+			setRef(plumbing.NewRemoteReferenceName("origin", "feature"), tt.remote)
+
+			got, err := repo.Tracking("feature")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Ahead != tt.ahead || got.Behind != tt.behind {
+				t.Errorf("Tracking() = %d ahead, %d behind, want %d ahead, %d behind", got.Ahead, got.Behind, tt.ahead, tt.behind)
+			}
+			if got.Remote != "origin" || got.Branch != "feature" {
+				t.Errorf("Tracking() = %s/%s, want origin/feature", got.Remote, got.Branch)
+			}
+		})
+	}
+}
+
+func TestTrackingOtherBranch(t *testing.T) {
+	repoPath := t.TempDir()
+	goGitRepo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(repoPath, ".git", "config")
+	branchConfig := "[remote \"origin\"]\n\turl = git@github.com:org/repo.git\n" +
+		"[branch \"fix\"]\n\tremote = origin\n\tmerge = refs/heads/release-1.2\n"
+	writeFile(t, configPath, branchConfig)
+
+	worktree, err := goGitRepo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()}
+	base, err := worktree.Commit("base", &git.CommitOptions{Author: signature, AllowEmptyCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix, err := worktree.Commit("fix", &git.CommitOptions{Author: signature, Parents: []plumbing.Hash{base}, AllowEmptyCommits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, hash := range map[plumbing.ReferenceName]plumbing.Hash{
+		plumbing.NewBranchReferenceName("fix"):                   fix,
+		plumbing.NewRemoteReferenceName("origin", "release-1.2"): base,
+	} {
+		if err := goGitRepo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err := FindInParents(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Branch created from release-1.2 is pushed to its own branch
+	_, err = repo.Tracking("fix")
+	if !errors.Is(err, ErrNoTrackingBranch) {
+		t.Fatalf("Tracking() error = %v, want ErrNoTrackingBranch", err)
+	}
+
+	err = goGitRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "fix"), base))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.Tracking("fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Tracking{Remote: "origin", Branch: "fix", Ahead: 1}
+	if got != want {
+		t.Errorf("Tracking() = %+v, want %+v", got, want)
+	}
+
+	// Tracked branch is pushed to only when configured explicitly
+	writeFile(t, configPath, branchConfig+"[push]\n\tdefault = upstream\n")
+
+	got, err = repo.Tracking("fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Tracking{Remote: "origin", Branch: "release-1.2", Ahead: 1}
+	if got != want {
+		t.Errorf("Tracking() with push.default=upstream = %+v, want %+v", got, want)
+	}
+}